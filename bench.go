@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"./engine"
+	"./record"
+	"./sim"
+)
+
+// bench plays simulated games and reports how well a strategy does
+func bench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	strategyName := strategyFlag(flags)
+	chord := chordFlag(flags)
+	validate := flags.Bool("validate", false, "check every certain move with the SAT oracle")
+	games := flags.Int("games", 1000, "number of games per level")
+	seed := flags.Int64("seed", 1, "seed for mine layouts and random clicks")
+	levelNames := flags.String("level", "all", "comma-separated levels: beginner, intermediate, expert, custom or all")
+	width := flags.Int("width", 30, "custom level width")
+	height := flags.Int("height", 16, "custom level height")
+	mines := flags.Int("mines", 99, "custom level mines")
+	recordPath := recordFlag(flags)
+	newOpening := openingFlags(flags)
+	openings := flags.Bool("openings", false, "compare opening policies instead of playing")
+	flags.Parse(args)
+
+	var recorder *record.Recorder
+	if *recordPath != "" {
+		var file *os.File
+		recorder, file = startRecording(*recordPath)
+		defer file.Close()
+	}
+
+	custom := sim.Level{Name: "custom", Width: *width, Height: *height, Mines: *mines}
+	var levels []sim.Level
+	for _, name := range strings.Split(*levelNames, ",") {
+		switch name {
+		case "all":
+			levels = append(levels, sim.Levels...)
+		case "custom":
+			levels = append(levels, custom)
+		default:
+			level, ok := findLevel(name)
+			if !ok {
+				log.Fatalln("Unknown level:", name)
+			}
+			levels = append(levels, level)
+		}
+	}
+
+	opening, rule := newOpening()
+	if *openings {
+		for _, level := range levels {
+			reportOpenings(level, rule, *games, *seed)
+		}
+		return
+	}
+
+	for _, level := range levels {
+		strategy := newStrategy(*strategyName, *chord)
+		board := level.NewBoard(*seed)
+		board.FirstClick = rule
+		bot := engine.NewEngine(board)
+		bot.SetStrategy(strategy)
+		bot.SetOpening(opening, rule)
+		bot.SetValidation(*validate)
+		bot.SetSeed(*seed)
+		if recorder != nil {
+			recorder.Layout = board.Layout
+			recorder.Seed = bot.GameSeed
+			bot.SetObserver(recorder)
+		}
+
+		log.SetOutput(ioutil.Discard)
+		result, err := sim.Play(bot, board, *games)
+		log.SetOutput(os.Stderr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s %dx%d/%d %s: %s\n", level.Name, level.Width, level.Height, level.Mines, *strategyName, result)
+		if *validate {
+			fmt.Println("unproven moves:", bot.Mistakes())
+		}
+	}
+}
+
+func findLevel(name string) (sim.Level, bool) {
+	for _, level := range sim.Levels {
+		if level.Name == name {
+			return level, true
+		}
+	}
+	return sim.Level{}, false
+}
+
+// reportOpenings compares opening policies on a level: the expected chance of a zero
+// against the simulated one, and the average number of tiles opened by the first click
+func reportOpenings(level sim.Level, rule engine.FirstClickRule, games int, seed int64) {
+	fmt.Printf("%s %dx%d/%d openings, %s first click:\n", level.Name, level.Width, level.Height, level.Mines, rule)
+	report := func(name string, at image.Point) {
+		rate, opened := level.Opening(at, rule, games, seed)
+		fmt.Printf("  %-8s at %2d %2d: %5.1f%% expected, %5.1f%% simulated, %6.1f tiles opened\n", name, at.X, at.Y,
+			engine.OpeningProbability(level.Width, level.Height, level.Mines, at, rule)*100, rate*100, opened)
+	}
+	for _, name := range engine.Openings() {
+		policy, _ := engine.ParseOpening(name)
+		report(name, policy.Opening(level.Width, level.Height, level.Mines))
+	}
+	// learned on other layouts than the ones it is measured on
+	at, _ := level.LearnOpening(rule, games, seed+1)
+	report("learned", at)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"./vision"
+)
+
+// defaultCalibration is loaded at startup when present
+const defaultCalibration = "calibration.json"
+
+// calibrationFlag registers the common -calibration flag
+func calibrationFlag(flags *flag.FlagSet) *string {
+	return flags.String("calibration", defaultCalibration, "file of learned tile hashes, made by the calibrate command")
+}
+
+// loadCalibration replaces compiled-in hashes with a calibration file, a missing default file is fine
+func loadCalibration(path string) {
+	if path == "" {
+		return
+	}
+	calibration, err := vision.LoadCalibration(path)
+	if os.IsNotExist(err) && path == defaultCalibration {
+		return
+	}
+	if err == nil {
+		err = calibration.Apply()
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+	log.Println("🎯 Using calibration", path)
+}
+
+// calibrate learns tile and counter digit hashes from window screenshots of known states:
+// a fresh board, a revealed one and a lost game showing bombs. Similar images are clustered,
+// clusters close to the compiled-in hashes are labelled automatically and the rest are
+// labelled by the user looking at saved samples. A screenshot given as fresh.png=99
+// labels the counter digits with the value it shows.
+func calibrate(args []string) {
+	flags := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	out := flags.String("out", defaultCalibration, "calibration file to write")
+	samples := flags.String("samples", "debug/clusters", "directory for sample images of clusters")
+	auto := flags.Bool("auto", false, "label only by the reference hashes, skip clusters without a match")
+	maxDistance := flags.Int("max-distance", vision.DefaultMaxDistance, "largest Hamming distance within a cluster")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal("Usage: calibrate [flags] screenshot.png[=counter]...")
+	}
+
+	clustering := vision.Clustering{MaxDistance: *maxDistance}
+	for _, arg := range flags.Args() {
+		path, counter, err := counterArg(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		img, err := vision.LoadPNG(path)
+		if err == nil && counter >= 0 {
+			err = clustering.AddCounterScreenshot(img, counter)
+		} else if err == nil {
+			err = clustering.AddScreenshot(img)
+		}
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+	}
+	if err := os.MkdirAll(*samples, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	var calibration vision.Calibration
+	input := bufio.NewScanner(os.Stdin)
+	for _, cluster := range clustering.Clusters {
+		kind := "tile"
+		if cluster.Digit {
+			kind = "digit"
+		}
+		sample := filepath.Join(*samples, fmt.Sprintf("%s_%016X.png", kind, uint64(cluster.Hash)))
+		if err := writePNG(sample, cluster); err != nil {
+			log.Fatal(err)
+		}
+		label, ok := cluster.Label, cluster.Label != ""
+		if ok {
+			log.Printf("%s %016X (%d seen) is %q on the counter", kind, uint64(cluster.Hash), cluster.Members, label)
+		} else if label, ok = cluster.ReferenceLabel(*maxDistance); ok {
+			log.Printf("%s %016X (%d seen) matches reference %q", kind, uint64(cluster.Hash), cluster.Members, label)
+		} else if *auto {
+			log.Printf("%s %016X (%d seen) has no reference, skipped", kind, uint64(cluster.Hash), cluster.Members)
+			continue
+		} else {
+			label, ok = ask(input, kind, sample, cluster.Members)
+			if !ok {
+				continue
+			}
+		}
+		calibration.Add(cluster, label)
+	}
+
+	// make sure the labels are usable before writing them
+	if err := calibration.Apply(); err != nil {
+		log.Fatal(err)
+	}
+	if err := calibration.Save(*out); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("💾 Saved %d tiles and %d digits to %s", len(calibration.Tiles), len(calibration.Digits), *out)
+}
+
+// counterArg splits a screenshot argument like lost.png=42 into the path and the value
+// its counter shows, -1 when the value is not given
+func counterArg(arg string) (string, int, error) {
+	i := strings.LastIndex(arg, "=")
+	if i < 0 {
+		return arg, -1, nil
+	}
+	counter, err := strconv.Atoi(arg[i+1:])
+	if err != nil || counter < 0 {
+		return "", 0, fmt.Errorf("Bad counter value in %q", arg)
+	}
+	return arg[:i], counter, nil
+}
+
+// ask prompts for a label of a cluster, an empty answer skips it
+func ask(input *bufio.Scanner, kind, sample string, members int) (string, bool) {
+	hint := "? unknown, . open, 1-8, F flag, * bomb"
+	if kind == "digit" {
+		hint = "0-9"
+	}
+	fmt.Printf("Label of %s %s (%d seen) [%s, empty to skip]: ", kind, sample, members, hint)
+	if !input.Scan() {
+		return "", false
+	}
+	label := strings.TrimSpace(input.Text())
+	return label, label != ""
+}
+
+func writePNG(path string, cluster vision.Cluster) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, cluster.Sample)
+}
@@ -0,0 +1,329 @@
+package engine
+
+import (
+	"image"
+	"math/bits"
+)
+
+// Bitboard is a packed board: one bit per tile in masks of unknown, flagged, revealed and bombed tiles,
+// and the numbers of revealed tiles in four bit planes. Each row takes whole 64-bit words,
+// bits past the width stay zero so that shifted neighbourhoods never see outside the board.
+type Bitboard struct {
+	width, height int
+	words         int // words per row
+	unknown       []uint64
+	flagged       []uint64
+	revealed      []uint64
+	bombs         []uint64
+	numbers       [4][]uint64
+}
+
+// NewBitboard creates a packed board of unknown tiles
+func NewBitboard(width, height int) *Bitboard {
+	words := (width + 63) / 64
+	bb := &Bitboard{width: width, height: height, words: words}
+	size := words * height
+	for _, mask := range []*[]uint64{&bb.unknown, &bb.flagged, &bb.revealed, &bb.bombs,
+		&bb.numbers[0], &bb.numbers[1], &bb.numbers[2], &bb.numbers[3]} {
+		*mask = make([]uint64, size)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			bb.unknown[bb.word(x, y)] |= bit(x)
+		}
+	}
+	return bb
+}
+
+// Pack converts a board to its packed form
+func Pack(b Board) *Bitboard {
+	bb := NewBitboard(b.Width(), b.Height())
+	for y := range b {
+		for x, tile := range b[y] {
+			if tile != Unknown {
+				bb.Set(x, y, tile)
+			}
+		}
+	}
+	return bb
+}
+
+// Board unpacks the board
+func (bb *Bitboard) Board() Board {
+	board := NewBoard(bb.width, bb.height)
+	for y := range board {
+		for x := range board[y] {
+			board[y][x] = bb.At(x, y)
+		}
+	}
+	return board
+}
+
+// Width of the board
+func (bb *Bitboard) Width() int {
+	return bb.width
+}
+
+// Height of the board
+func (bb *Bitboard) Height() int {
+	return bb.height
+}
+
+// Clone makes an independent copy of the board
+func (bb *Bitboard) Clone() *Bitboard {
+	clone := *bb
+	for _, mask := range []*[]uint64{&clone.unknown, &clone.flagged, &clone.revealed, &clone.bombs,
+		&clone.numbers[0], &clone.numbers[1], &clone.numbers[2], &clone.numbers[3]} {
+		*mask = append([]uint64(nil), *mask...)
+	}
+	return &clone
+}
+
+func (bb *Bitboard) word(x, y int) int {
+	return y*bb.words + x/64
+}
+
+func bit(x int) uint64 {
+	return 1 << uint(x%64)
+}
+
+// At returns the tile at given coordinates
+func (bb *Bitboard) At(x, y int) Tile {
+	i, m := bb.word(x, y), bit(x)
+	switch {
+	case bb.unknown[i]&m != 0:
+		return Unknown
+	case bb.flagged[i]&m != 0:
+		return Flag
+	case bb.bombs[i]&m != 0:
+		return Bomb
+	}
+	if number := bb.number(x, y); number != 0 {
+		return Tile(number)
+	}
+	return OpenSpace
+}
+
+// Set puts a tile at given coordinates
+func (bb *Bitboard) Set(x, y int, tile Tile) {
+	i, m := bb.word(x, y), bit(x)
+	bb.unknown[i] &^= m
+	bb.flagged[i] &^= m
+	bb.revealed[i] &^= m
+	bb.bombs[i] &^= m
+	for plane := range bb.numbers {
+		bb.numbers[plane][i] &^= m
+	}
+	switch {
+	case tile == Unknown:
+		bb.unknown[i] |= m
+	case tile == Flag:
+		bb.flagged[i] |= m
+	case tile == Bomb:
+		bb.bombs[i] |= m
+	case tile == OpenSpace:
+		bb.revealed[i] |= m
+	case tile >= 1 && tile <= 8:
+		bb.revealed[i] |= m
+		for plane := range bb.numbers {
+			if tile&(1<<uint(plane)) != 0 {
+				bb.numbers[plane][i] |= m
+			}
+		}
+	}
+}
+
+// Contains reports that some tile of the board has a given value
+func (bb *Bitboard) Contains(value Tile) bool {
+	var mask []uint64
+	switch value {
+	case Unknown:
+		mask = bb.unknown
+	case Flag:
+		mask = bb.flagged
+	case Bomb:
+		mask = bb.bombs
+	default:
+		for y := 0; y < bb.height; y++ {
+			for x := 0; x < bb.width; x++ {
+				if bb.At(x, y) == value {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, word := range mask {
+		if word != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// triple returns bits of columns x-1, x and x+1 of a mask row as the three lowest bits,
+// columns outside the board read as zero
+func (bb *Bitboard) triple(mask []uint64, x, y int) uint64 {
+	if y < 0 || y >= bb.height {
+		return 0
+	}
+	row := mask[y*bb.words : (y+1)*bb.words]
+	if x == 0 {
+		return (row[0] & 3) << 1
+	}
+	w, offset := (x-1)/64, uint((x-1)%64)
+	v := row[w] >> offset
+	if offset > 61 && w+1 < len(row) {
+		v |= row[w+1] << (64 - offset)
+	}
+	return v & 7
+}
+
+// countAround counts set bits of a mask around a tile, the tile itself excluded:
+// the three rows of the neighbourhood are packed into one word for a single population count
+func (bb *Bitboard) countAround(mask []uint64, x, y int) int {
+	window := bb.triple(mask, x, y-1)<<6 | bb.triple(mask, x, y)<<3 | bb.triple(mask, x, y+1)
+	return bits.OnesCount64(window &^ (2 << 3))
+}
+
+// Counts returns numbers of unknowns and flags around a tile
+func (bb *Bitboard) Counts(x, y int) (unknownCount, flagCount int) {
+	return bb.countAround(bb.unknown, x, y), bb.countAround(bb.flagged, x, y)
+}
+
+// around returns the rectangle of a tile and its neighbours within the board
+func (bb *Bitboard) around(x0, y0 int) image.Rectangle {
+	return image.Rect(max(0, x0-1), max(0, y0-1), min(bb.width, x0+2), min(bb.height, y0+2))
+}
+
+// IsUnknown reports that the tile at given coordinates is unknown
+func (bb *Bitboard) IsUnknown(x, y int) bool {
+	return bb.unknown[bb.word(x, y)]&bit(x) != 0
+}
+
+// number returns the number of a revealed tile, 0 for any other tile
+func (bb *Bitboard) number(x, y int) int {
+	i, m := bb.word(x, y), bit(x)
+	number := 0
+	for plane := range bb.numbers {
+		if bb.numbers[plane][i]&m != 0 {
+			number |= 1 << uint(plane)
+		}
+	}
+	return number
+}
+
+// UnknownCounts returns numbers of unknowns around every tile, indexed y*width+x
+func (bb *Bitboard) UnknownCounts() []uint8 {
+	return bb.neighbourCounts(bb.unknown)
+}
+
+// FlagCounts returns numbers of flags around every tile, indexed y*width+x
+func (bb *Bitboard) FlagCounts() []uint8 {
+	return bb.neighbourCounts(bb.flagged)
+}
+
+// neighbourCounts counts set bits of a mask around all tiles at once: the eight shifted neighbour rows
+// are summed by bit-sliced adders into four count planes, a whole word of tiles at a time
+func (bb *Bitboard) neighbourCounts(mask []uint64) []uint8 {
+	counts := make([]uint8, bb.width*bb.height)
+	var inputs [8]uint64
+	for y := 0; y < bb.height; y++ {
+		for w := 0; w < bb.words; w++ {
+			n := 0
+			for dy := -1; dy <= 1; dy++ {
+				if y+dy < 0 || y+dy >= bb.height {
+					continue
+				}
+				row := mask[(y+dy)*bb.words : (y+dy+1)*bb.words]
+				left, right := row[w]<<1, row[w]>>1
+				if w > 0 {
+					left |= row[w-1] >> 63
+				}
+				if w+1 < len(row) {
+					right |= row[w+1] << 63
+				}
+				inputs[n], inputs[n+1] = left, right
+				n += 2
+				if dy != 0 {
+					inputs[n] = row[w]
+					n++
+				}
+			}
+			var planes [4]uint64
+			for _, carry := range inputs[:n] {
+				for p := range planes {
+					next := planes[p] & carry
+					planes[p] ^= carry
+					carry = next
+				}
+			}
+			for x := w * 64; x < min(bb.width, (w+1)*64); x++ {
+				m := bit(x)
+				var count uint8
+				for p := range planes {
+					if planes[p]&m != 0 {
+						count |= 1 << uint(p)
+					}
+				}
+				counts[y*bb.width+x] = count
+			}
+		}
+	}
+	return counts
+}
+
+// Neighbours returns tiles around a given one with their coordinates, and counts of unknowns and flags among them,
+// just like Board.Neighbours
+func (bb *Bitboard) Neighbours(x0, y0 int) ([]Tile, []image.Point, int, int) {
+	var tiles = []Tile{}
+	var coords = []image.Point{}
+	for y := max(0, y0-1); y < min(bb.height, y0+2); y++ {
+		for x := max(0, x0-1); x < min(bb.width, x0+2); x++ {
+			if x != x0 || y != y0 {
+				coords = append(coords, image.Pt(x, y))
+				tiles = append(tiles, bb.At(x, y))
+			}
+		}
+	}
+	unknownCount, flagCount := bb.Counts(x0, y0)
+	return tiles, coords, unknownCount, flagCount
+}
+
+// spread returns a mask with every set bit of a row also set in both its horizontal neighbours
+func (bb *Bitboard) spread(mask []uint64, y int, dst []uint64) {
+	row := mask[y*bb.words : (y+1)*bb.words]
+	for w := range row {
+		v := row[w] | row[w]<<1 | row[w]>>1
+		if w > 0 {
+			v |= row[w-1] >> 63
+		}
+		if w+1 < len(row) {
+			v |= row[w+1] << 63
+		}
+		dst[w] |= v
+	}
+}
+
+// FrontierTiles returns numbered tiles having unknown neighbours in reading order,
+// the unknown mask is spread to neighbours by shifts a whole word at a time
+func (bb *Bitboard) FrontierTiles() []image.Point {
+	var tiles []image.Point
+	near := make([]uint64, bb.words)
+	for y := 0; y < bb.height; y++ {
+		for w := range near {
+			near[w] = 0
+		}
+		for ny := max(0, y-1); ny < min(bb.height, y+2); ny++ {
+			bb.spread(bb.unknown, ny, near)
+		}
+		for w := range near {
+			i := y*bb.words + w
+			numbered := bb.numbers[0][i] | bb.numbers[1][i] | bb.numbers[2][i] | bb.numbers[3][i]
+			for v := near[w] & numbered; v != 0; v &= v - 1 {
+				tiles = append(tiles, image.Pt(w*64+bits.TrailingZeros64(v), y))
+			}
+		}
+	}
+	return tiles
+}
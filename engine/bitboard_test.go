@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"image"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// midGame makes a board played halfway: mines laid at random, a share of safe tiles
+// revealed with their numbers and some of the mines next to them flagged
+func midGame(width, height, mines int, seed int64) Board {
+	rng := rand.New(rand.NewSource(seed))
+	mined := map[int]bool{}
+	for _, i := range rng.Perm(width * height)[:mines] {
+		mined[i] = true
+	}
+	board := NewBoard(width, height)
+	for _, i := range rng.Perm(width * height)[:width*height/2] {
+		if mined[i] {
+			continue
+		}
+		x, y := i%width, i/width
+		count := 0
+		r := board.around(x, y)
+		for ny := r.Min.Y; ny < r.Max.Y; ny++ {
+			for nx := r.Min.X; nx < r.Max.X; nx++ {
+				if mined[ny*width+nx] {
+					count++
+				}
+			}
+		}
+		board[y][x] = Tile(count)
+		if count == 0 {
+			board[y][x] = OpenSpace
+		}
+	}
+	for i := range mined {
+		x, y := i%width, i/width
+		if unknownCount, _ := board.Counts(x, y); unknownCount < 8 && rng.Intn(2) == 0 {
+			board[y][x] = Flag
+		}
+	}
+	return board
+}
+
+// sizes cover rows of a single word, of exactly one and of several words
+var sizes = []struct{ width, height, mines int }{
+	{9, 9, 10}, {30, 16, 99}, {64, 8, 80}, {65, 5, 60}, {200, 40, 1200},
+}
+
+func TestBitboardMatchesBoard(t *testing.T) {
+	for _, size := range sizes {
+		for seed := int64(1); seed <= 5; seed++ {
+			board := midGame(size.width, size.height, size.mines, seed)
+			packed := Pack(board)
+			if !reflect.DeepEqual(packed.Board(), board) {
+				t.Fatalf("%dx%d: packed board does not unpack to the original", size.width, size.height)
+			}
+			unknowns, flags := packed.UnknownCounts(), packed.FlagCounts()
+			for y := range board {
+				for x := range board[y] {
+					unknownCount, flagCount := board.Counts(x, y)
+					if u, f := packed.Counts(x, y); u != unknownCount || f != flagCount {
+						t.Fatalf("%dx%d: counts at %d %d are %d, %d, want %d, %d",
+							size.width, size.height, x, y, u, f, unknownCount, flagCount)
+					}
+					if i := y*size.width + x; int(unknowns[i]) != unknownCount || int(flags[i]) != flagCount {
+						t.Fatalf("%dx%d: counts of all tiles differ at %d %d", size.width, size.height, x, y)
+					}
+				}
+			}
+			var frontier []image.Point
+			for y := range board {
+				for x, tile := range board[y] {
+					if unknownCount, _ := board.Counts(x, y); tile >= 1 && tile <= 8 && unknownCount > 0 {
+						frontier = append(frontier, image.Pt(x, y))
+					}
+				}
+			}
+			if !reflect.DeepEqual(packed.FrontierTiles(), frontier) {
+				t.Fatalf("%dx%d: packed frontier differs", size.width, size.height)
+			}
+		}
+	}
+}
+
+func TestFrontierUpdateKeepsPackedBoard(t *testing.T) {
+	board := midGame(30, 16, 99, 1)
+	frontier := NewFrontier(board)
+	frontier.TakeDirty()
+	rng := rand.New(rand.NewSource(1))
+	for step := 0; step < 50; step++ {
+		var changed []image.Point
+		for _, i := range rng.Perm(30 * 16)[:5] {
+			x, y := i%30, i/30
+			if board[y][x] == Unknown {
+				board[y][x] = []Tile{OpenSpace, 1, 2, 3}[rng.Intn(4)]
+				changed = append(changed, image.Pt(x, y))
+			}
+		}
+		frontier.Update(board, changed)
+		fresh := NewFrontier(board)
+		if !reflect.DeepEqual(frontier.Packed().Board(), board) {
+			t.Fatalf("step %d: packed board is out of date", step)
+		}
+		if !reflect.DeepEqual(frontier.Tiles(), fresh.Tiles()) || frontier.Len() != fresh.Len() {
+			t.Fatalf("step %d: frontier %v, want %v", step, frontier.Tiles(), fresh.Tiles())
+		}
+		for _, c := range frontier.TakeDirty() {
+			if unknownCount, _ := board.Counts(c.X, c.Y); unknownCount == 0 {
+				t.Fatalf("step %d: dirty tile %v is off the frontier", step, c)
+			}
+		}
+	}
+}
+
+func TestTileRulesLeaveBoardAlone(t *testing.T) {
+	board, err := ParseBoard([]string{
+		"1??",
+		"1??",
+		"111",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frontier := NewFrontier(board)
+	actions := tileRules(frontier)
+	if len(actions) == 0 {
+		t.Fatal("no moves found")
+	}
+	if !reflect.DeepEqual(frontier.Packed().Board(), board) {
+		t.Error("deductions changed the packed board of the frontier")
+	}
+}
+
+// benchBoard is a large board played halfway
+var benchBoard = midGame(200, 200, 6000, 1)
+
+func BenchmarkCounts(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for y := range benchBoard {
+			for x := range benchBoard[y] {
+				benchBoard.Counts(x, y)
+			}
+		}
+	}
+}
+
+func BenchmarkBitboardCounts(b *testing.B) {
+	packed := Pack(benchBoard)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for y := range benchBoard {
+			for x := range benchBoard[y] {
+				packed.Counts(x, y)
+			}
+		}
+	}
+}
+
+func BenchmarkBitboardAllCounts(b *testing.B) {
+	packed := Pack(benchBoard)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		packed.UnknownCounts()
+		packed.FlagCounts()
+	}
+}
+
+func BenchmarkNeighbours(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for y := range benchBoard {
+			for x := range benchBoard[y] {
+				benchBoard.Neighbours(x, y)
+			}
+		}
+	}
+}
+
+func BenchmarkBitboardNeighbours(b *testing.B) {
+	packed := Pack(benchBoard)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for y := range benchBoard {
+			for x := range benchBoard[y] {
+				packed.Neighbours(x, y)
+			}
+		}
+	}
+}
+
+func BenchmarkNewFrontier(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewFrontier(benchBoard)
+	}
+}
+
+func BenchmarkBitboardFrontierTiles(b *testing.B) {
+	packed := Pack(benchBoard)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		packed.FrontierTiles()
+	}
+}
+
+func BenchmarkTileRules(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tileRules(NewFrontier(benchBoard))
+	}
+}
+
+func BenchmarkClone(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchBoard.Clone()
+	}
+}
+
+func BenchmarkBitboardClone(b *testing.B) {
+	packed := Pack(benchBoard)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		packed.Clone()
+	}
+}
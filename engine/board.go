@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+)
+
+// Board is a snapshot of the playing field, indexed as [y][x]
+type Board [][]Tile
+
+// NewBoard creates a board of unknown tiles
+func NewBoard(width, height int) Board {
+	// single-allocation method
+	board := make(Board, height)
+	cells := make([]Tile, width*height)
+	for i := range board {
+		board[i], cells = cells[:width], cells[width:]
+	}
+	return board
+}
+
+// Width of the board
+func (b Board) Width() int {
+	if len(b) == 0 {
+		return 0
+	}
+	return len(b[0])
+}
+
+// Height of the board
+func (b Board) Height() int {
+	return len(b)
+}
+
+// Clone makes an independent copy of the board
+func (b Board) Clone() Board {
+	clone := NewBoard(b.Width(), b.Height())
+	for y := range b {
+		copy(clone[y], b[y])
+	}
+	return clone
+}
+
+func (b Board) String() string {
+	var buf bytes.Buffer
+	for _, line := range b {
+		buf.WriteString(tilesString(line))
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// Characters of tiles in text form
+var tileChars = map[Tile]byte{
+	Unknown:   '?',
+	OpenSpace: '.',
+	Flag:      'F',
+	Bomb:      '*',
+}
+
+// Rows returns the board in text form, one string per row:
+// '?' unknown, '.' open space, '1'-'8' numbers, 'F' flag, '*' bomb
+func (b Board) Rows() []string {
+	rows := make([]string, len(b))
+	for y, line := range b {
+		row := make([]byte, len(line))
+		for x, tile := range line {
+			if c, ok := tileChars[tile]; ok {
+				row[x] = c
+			} else {
+				row[x] = '0' + byte(tile)
+			}
+		}
+		rows[y] = string(row)
+	}
+	return rows
+}
+
+// ParseBoard reads a board in text form produced by Rows
+func ParseBoard(rows []string) (Board, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	board := NewBoard(len(rows[0]), len(rows))
+	for y, row := range rows {
+		if len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("Ragged board row %d", y)
+		}
+	nextTile:
+		for x := 0; x < len(row); x++ {
+			c := row[x]
+			if c >= '1' && c <= '8' {
+				board[y][x] = Tile(c - '0')
+				continue
+			}
+			for tile, char := range tileChars {
+				if char == c {
+					board[y][x] = tile
+					continue nextTile
+				}
+			}
+			return nil, fmt.Errorf("Unknown tile %q at %d %d", c, x, y)
+		}
+	}
+	return board, nil
+}
+
+// Contains reports that some tile of the board has a given value
+func (b Board) Contains(value Tile) bool {
+	for y := range b {
+		for _, tile := range b[y] {
+			if tile == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Neighbours returns tiles around a given one with their coordinates, and counts of unknowns and flags among them
+func (b Board) Neighbours(x0, y0 int) ([]Tile, []image.Point, int, int) {
+	var tiles = []Tile{}
+	var coords = []image.Point{}
+	var unknownCount, flagCount int
+	var tile Tile
+	for y := max(0, y0-1); y < min(b.Height(), y0+2); y++ {
+		for x := max(0, x0-1); x < min(b.Width(), x0+2); x++ {
+			if x != x0 || y != y0 {
+				tile = b[y][x]
+				if tile == Unknown {
+					unknownCount++
+				}
+				if tile == Flag {
+					flagCount++
+				}
+				coords = append(coords, image.Pt(x, y))
+				tiles = append(tiles, tile)
+			}
+		}
+	}
+	return tiles, coords, unknownCount, flagCount
+}
+
+// around returns the 3x3 neighbourhood of a tile clipped to the board, the tile included
+func (b Board) around(x0, y0 int) image.Rectangle {
+	return image.Rect(max(0, x0-1), max(0, y0-1), min(b.Width(), x0+2), min(b.Height(), y0+2))
+}
+
+// Counts returns numbers of unknowns and flags around a tile, like Neighbours but without allocating
+func (b Board) Counts(x0, y0 int) (unknownCount, flagCount int) {
+	r := b.around(x0, y0)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			switch b[y][x] {
+			case Unknown:
+				if x != x0 || y != y0 {
+					unknownCount++
+				}
+			case Flag:
+				if x != x0 || y != y0 {
+					flagCount++
+				}
+			}
+		}
+	}
+	return unknownCount, flagCount
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"image"
+	"log"
+)
+
+// Chorder is implemented by actuators that can chord: clicking a number
+// that has all its flags placed reveals all its other neighbours at once.
+// When flags are wrong the game reveals a mine, just like a wrong click.
+type Chorder interface {
+	Chord(x, y int)
+}
+
+// minChordReveals is the least number of tiles a chord has to open to beat a plain click
+const minChordReveals = 2
+
+// Chords wraps a strategy, replacing its proven reveals next to a number with all flags placed
+// by a single chord on that number. Only flags already on the board count.
+type Chords struct {
+	Strategy
+}
+
+// Decide implements Strategy
+func (c Chords) Decide(board Board, minesLeft int) []Action {
+	return chords(board, c.Strategy.Decide(board, minesLeft))
+}
+
+// DecideFrontier implements FrontierStrategy
+func (c Chords) DecideFrontier(board Board, minesLeft int, frontier *Frontier) []Action {
+	return chords(board, decide(c.Strategy, board, minesLeft, frontier))
+}
+
+// chords replaces proven reveals by chords where one chord opens several of them
+func chords(board Board, actions []Action) []Action {
+	covered := map[image.Point]bool{}
+	result := make([]Action, 0, len(actions))
+	for _, a := range actions {
+		if a.Kind != RevealAction || a.Confidence < 1 {
+			result = append(result, a)
+			continue
+		}
+		if covered[a.At] {
+			continue
+		}
+		if number, unknowns, ok := chordFor(board, a.At); ok {
+			for _, u := range unknowns {
+				covered[u] = true
+			}
+			result = append(result, Action{Kind: ChordAction, At: number, Confidence: 1, Rule: a.Rule})
+			continue
+		}
+		result = append(result, a)
+	}
+	return result
+}
+
+// chordFor finds a satisfied number next to a tile whose chord opens enough tiles
+func chordFor(board Board, at image.Point) (image.Point, []image.Point, bool) {
+	_, around, _, _ := board.Neighbours(at.X, at.Y)
+	for _, n := range around {
+		if unknowns, ok := chordable(board, n); ok {
+			return n, unknowns, true
+		}
+	}
+	return image.Point{}, nil, false
+}
+
+// chordable reports that a numbered tile has all its flags and returns its unknown neighbours
+func chordable(board Board, at image.Point) ([]image.Point, bool) {
+	tile := board[at.Y][at.X]
+	if tile < 1 || tile > 8 {
+		return nil, false
+	}
+	_, coords, unknownCount, flagCount := board.Neighbours(at.X, at.Y)
+	if flagCount != int(tile) || unknownCount < minChordReveals {
+		return nil, false
+	}
+	unknowns := make([]image.Point, 0, unknownCount)
+	for _, c := range coords {
+		if board[c.Y][c.X] == Unknown {
+			unknowns = append(unknowns, c)
+		}
+	}
+	return unknowns, true
+}
+
+// chord reveals unknown neighbours of a number, one by one when the actuator cannot chord
+func (e *engine) chord(x, y int) {
+	if chorder, ok := e.actuator.(Chorder); ok {
+		chorder.Chord(x, y)
+		return
+	}
+	_, coords, _, _ := e.field.Neighbours(x, y)
+	for _, c := range coords {
+		if e.field[c.Y][c.X] == Unknown {
+			log.Println("Clicking on", c.X, c.Y)
+			e.LeftClick(c.X, c.Y)
+		}
+	}
+}
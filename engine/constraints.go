@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"image"
+	"sort"
+)
+
+// constraint states that exactly mines of cells are mines.
+// Cells are tile indices y*width+x, kept sorted.
+type constraint struct {
+	cells []int
+	mines int
+}
+
+// constraints builds one constraint per numbered tile having unknown neighbours
+func (b Board) constraints() []constraint {
+	var result []constraint
+	width := b.Width()
+	for y := range b {
+		for x, tile := range b[y] {
+			if tile < 1 || tile > 8 {
+				continue
+			}
+			tiles, coords, unknownCount, flagCount := b.Neighbours(x, y)
+			if unknownCount == 0 {
+				continue
+			}
+			c := constraint{cells: make([]int, 0, unknownCount), mines: int(tile) - flagCount}
+			for i, t := range tiles {
+				if t == Unknown {
+					c.cells = append(c.cells, coords[i].Y*width+coords[i].X)
+				}
+			}
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// split returns cells only in a, in both, and only in b
+func split(a, b []int) (onlyA, common, onlyB []int) {
+	var i, j int
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			onlyA = append(onlyA, a[i])
+			i++
+		case a[i] > b[j]:
+			onlyB = append(onlyB, b[j])
+			j++
+		default:
+			common = append(common, a[i])
+			i++
+			j++
+		}
+	}
+	onlyA = append(onlyA, a[i:]...)
+	onlyB = append(onlyB, b[j:]...)
+	return
+}
+
+// deduction collects cells proven safe or mined
+type deduction struct {
+	safe, mines map[int]bool
+}
+
+func newDeduction() deduction {
+	return deduction{safe: map[int]bool{}, mines: map[int]bool{}}
+}
+
+func (d deduction) markSafe(cells []int) {
+	for _, c := range cells {
+		d.safe[c] = true
+	}
+}
+
+func (d deduction) markMines(cells []int) {
+	for _, c := range cells {
+		d.mines[c] = true
+	}
+}
+
+func (d deduction) empty() bool {
+	return len(d.safe) == 0 && len(d.mines) == 0
+}
+
+// points converts a set of tile indices to sorted coordinates
+func points(set map[int]bool, width int) []image.Point {
+	indices := make([]int, 0, len(set))
+	for i := range set {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	result := make([]image.Point, len(indices))
+	for k, i := range indices {
+		result[k] = image.Pt(i%width, i/width)
+	}
+	return result
+}
+
+// SubsetDeduction compares overlapping constraints of numbered tiles pairwise.
+// For constraints A and B, mines in B\A are bounded by how many mines A can or must put into A∩B,
+// which covers subset differences as well as 1-1 and 1-2-1 patterns. Proven tiles are put on
+// a copy of the board and the comparison repeats, as the middle of 1-2-1 is only safe
+// once its neighbours are known to be mines.
+func (b Board) SubsetDeduction() (safe, mines []image.Point) {
+	width := b.Width()
+	work := b.Clone()
+	d := newDeduction()
+	for {
+		found := work.subsetPass()
+		if found.empty() {
+			break
+		}
+		for cell := range found.safe {
+			d.safe[cell] = true
+			work[cell/width][cell%width] = OpenSpace
+		}
+		for cell := range found.mines {
+			d.mines[cell] = true
+			work[cell/width][cell%width] = Flag
+		}
+	}
+	return points(d.safe, width), points(d.mines, width)
+}
+
+// subsetPass compares every pair of constraints once
+func (b Board) subsetPass() deduction {
+	cs := b.constraints()
+	d := newDeduction()
+	for _, c := range cs {
+		if c.mines == 0 {
+			d.markSafe(c.cells)
+		} else if c.mines == len(c.cells) {
+			d.markMines(c.cells)
+		}
+	}
+	for i := range cs {
+		for j := range cs {
+			if i != j {
+				compareConstraints(cs[i], cs[j], d)
+			}
+		}
+	}
+	return d
+}
+
+func compareConstraints(a, b constraint, d deduction) {
+	onlyA, common, onlyB := split(a.cells, b.cells)
+	if len(common) == 0 || len(onlyB) == 0 {
+		return
+	}
+	maxCommon := min(a.mines, len(common))
+	minCommon := max(0, a.mines-len(onlyA))
+	// All of B\A has to be mined
+	if b.mines-maxCommon == len(onlyB) {
+		d.markMines(onlyB)
+		// and then A∩B carries the rest of B, which may exhaust A
+		if b.mines-len(onlyB) == a.mines {
+			d.markSafe(onlyA)
+		}
+	}
+	// A∩B already holds every mine of B
+	if b.mines-minCommon == 0 {
+		d.markSafe(onlyB)
+	}
+}
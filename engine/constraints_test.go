@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestSubsetDeduction(t *testing.T) {
+	cases := []struct {
+		name        string
+		rows        []string
+		safe, mines []image.Point
+	}{
+		{"1-1 at a wall", []string{
+			"????",
+			"11??",
+		}, []image.Point{{2, 0}, {2, 1}}, nil},
+		{"1-2-1", []string{
+			"???",
+			"121",
+		}, []image.Point{{1, 0}}, []image.Point{{0, 0}, {2, 0}}},
+		{"1-2-2-1", []string{
+			"????",
+			"1221",
+		}, []image.Point{{0, 0}, {3, 0}}, []image.Point{{1, 0}, {2, 0}}},
+		{"subset difference mined", []string{
+			"???",
+			"13?",
+		}, nil, []image.Point{{2, 0}, {2, 1}}},
+		{"subset difference undecided", []string{
+			"???",
+			"12?",
+		}, nil, nil},
+		{"flags count", []string{
+			"F??",
+			"22?",
+		}, []image.Point{{2, 0}, {2, 1}}, []image.Point{{1, 0}}},
+		{"satisfied and full tiles", []string{
+			"?1?",
+			"?F?",
+			"???",
+		}, []image.Point{{0, 0}, {2, 0}, {0, 1}, {2, 1}}, nil},
+	}
+	for _, c := range cases {
+		b := parse(t, c.rows...)
+		safe, mines := b.SubsetDeduction()
+		if len(safe) == 0 {
+			safe = nil
+		}
+		if len(mines) == 0 {
+			mines = nil
+		}
+		if !reflect.DeepEqual(safe, c.safe) || !reflect.DeepEqual(mines, c.mines) {
+			t.Errorf("%s: safe %v, mines %v, want %v, %v", c.name, safe, mines, c.safe, c.mines)
+		}
+		// the expectations themselves follow from every layout
+		p, _ := bruteForce(b, -1)
+		for _, s := range c.safe {
+			if p[s] != 0 {
+				t.Errorf("%s: %v expected safe but is a mine with %v", c.name, s, p[s])
+			}
+		}
+		for _, m := range c.mines {
+			if p[m] != 1 {
+				t.Errorf("%s: %v expected a mine but is one with %v", c.name, m, p[m])
+			}
+		}
+	}
+}
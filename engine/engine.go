@@ -2,39 +2,16 @@ package engine
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"image"
-	"image/png"
 	"log"
 	"math/rand"
-	"os"
 	"time"
-
-	"github.com/jBugman/imghash"
-
-	"../macos"
-	"../macos/keycode"
-)
-
-const (
-	tileSize        = 32 // 64px on retina
-	headerHeight    = 22
-	footerHeight    = 31
-	restartMessageW = 214
-	restartMessageH = 32
-)
-const (
-	messageMask   ImageHash = 0x0300007E7E000000
-	zeroBombsHash ImageHash = 0xFF8F2737373787CF
 )
 
 // Tile represents possible tile values
 type Tile uint8
 
-// ImageHash represents average image hash
-type ImageHash uint64
-
 // Special Tile values
 const (
 	Unknown   Tile = 0
@@ -70,38 +47,73 @@ func (t Tile) String() string {
 	case OpenSpace:
 		return "🆓"
 	default:
-		return string(t)
+		return string(rune(t))
 	}
 }
 
-var tileHashes = map[ImageHash]Tile{
-	0x0000000000000000: Unknown, // Unknown tile (or maybe OpenSpace before colour check)
-	0xFFC3C3E7E7E3E3FF: 1,
-	0xFFC3E3E7CFC3E3FF: 2,
-	0xFFE3C3C7E7C3E3FF: 3,
-	0xFFEFC3C3E3E7EFFF: 4,
-	0xFFE3C3CFE3E3E3FF: 5,
-	0xFFE7C3C3E3E3E7FF: 6,
-	0xFFF3F7E7E7C3C3FF: 7,
-	0xFFE7C3C3E3C3E7FF: 8,
-	0xFFF7F7C3C381F3FF: Flag,
-	0xFFFFC3C3C3C3FFFF: Bomb,
+// FieldReader sees the game.
+// ReadField fills a field snapshot, skipping already known tiles when unknownsOnly is set.
+// Cleared reports that the game counts no more mines left.
+type FieldReader interface {
+	ReadField(field Board, unknownsOnly bool) error
+	Cleared() bool
+}
+
+// Actuator performs player input
+type Actuator interface {
+	NewGame()
+	Reveal(x, y int)
+	Flag(x, y int)
+}
+
+// Frontend is a playable game: something to look at and something to click on
+type Frontend interface {
+	Start() (width, height uint, err error)
+	FieldReader
+	Actuator
+}
+
+// Stats counts what the engine did during the current game
+type Stats struct {
+	Moves    int // clicks and flags
+	Guesses  int // moves that were not proven safe
+	Captures int // field reads
+	// Duration is the wall-clock time of the game, GameSeconds is the in-game timer
+	// at its end or -1 when the frontend cannot read it. Both are set when the game ends.
+	Duration    time.Duration
+	GameSeconds int
 }
 
 type engine struct {
-	x, y          int
 	width, height uint
-	windowID      int
-	field         [][]Tile
-	timerHash     ImageHash
-	bombCountHash ImageHash
-	ClickDuration time.Duration
+	field         Board
+	reader        FieldReader
+	actuator      Actuator
+	frontend      Frontend
+	strategy      Strategy
+	validate      bool
+	mistakes      int // moves found unproven by validation
+	stats         Stats
+	rng           *rand.Rand
+	// nextSeed seeds random clicks of the next game, gameSeed those of the current one
+	nextSeed, gameSeed int64
+	observer           Observer
+	started            time.Time
+	// readFailures counts unreadable fields in a row, flagsSinceCapture
+	// the flags the mine counter did not see yet
+	readFailures      int
+	flagsSinceCapture int
+	// frontier is kept up to date from unknowns, the tiles still unknown at the last decision
+	frontier *Frontier
+	unknowns []int
+	// opening picks the first click under the firstClick rule of the game
+	opening    OpeningPolicy
+	firstClick FirstClickRule
 }
 
 // Engine provides public interface
 type Engine interface {
 	Start() error
-	GrabScreen() image.Image
 	StartGame()
 	LeftClick(x, y int)
 	RightClick(x, y int)
@@ -109,74 +121,95 @@ type Engine interface {
 	UpdateField(unknownsOnly bool) error
 	GameLoop() bool
 	ClickRandomUnknown() bool
-	SetClickDuration(duration time.Duration)
+	SetStrategy(strategy Strategy)
+	SetValidation(validate bool)
+	Mistakes() int
+	Stats() Stats
+	SetSeed(seed int64)
+	GameSeed() int64
+	SetObserver(observer Observer)
+	SetOpening(policy OpeningPolicy, rule FirstClickRule)
 }
 
-// NewEngine creates engine instance
-func NewEngine() Engine {
-	return &engine{ClickDuration: macos.MouseClickDuration}
+// NewEngine creates engine instance playing on a provided frontend with the default strategy
+func NewEngine(frontend Frontend) Engine {
+	strategy, _ := NewStrategy(DefaultStrategy)
+	return &engine{
+		frontend: frontend,
+		reader:   frontend,
+		actuator: frontend,
+		strategy: strategy,
+		rng:      rand.New(rand.NewSource(0)),
+		nextSeed: time.Now().UnixNano(),
+	}
 }
 
 func (e *engine) Start() error {
-	const appTitle = "Minesweeper"
-	winMeta, err := macos.FindWindow(appTitle)
-	log.Printf("%+v\n", winMeta)
+	width, height, err := e.frontend.Start()
 	if err != nil {
 		return err
 	}
-
-	e.windowID = winMeta.ID
-	e.x = winMeta.Bounds.X()
-	e.y = winMeta.Bounds.Y() + headerHeight
-	e.width = winMeta.Bounds.Width() / tileSize
-	e.height = (winMeta.Bounds.Height() - headerHeight - footerHeight) / tileSize
-
-	// single-allocation method
-	e.field = make([][]Tile, e.height)
-	cells := make([]Tile, e.width*e.height)
-	for i := range e.field {
-		e.field[i], cells = cells[:e.width], cells[e.width:]
-	}
+	e.width, e.height = width, height
+	e.field = NewBoard(int(width), int(height))
 
 	log.Printf("%dx%d", e.width, e.height)
-
-	macos.ActivateWindow(winMeta.OwnerPID)
 	return nil
 }
 
-func (e *engine) SetClickDuration(duration time.Duration) {
-	e.ClickDuration = duration
+func (e *engine) SetStrategy(strategy Strategy) {
+	e.strategy = strategy
+}
+
+// SetValidation makes the engine check every certain move against the SAT oracle
+func (e *engine) SetValidation(validate bool) {
+	e.validate = validate
+}
+
+// Mistakes returns number of moves validation could not prove
+func (e engine) Mistakes() int {
+	return e.mistakes
+}
+
+// Stats returns counters of the current game
+func (e engine) Stats() Stats {
+	return e.stats
+}
+
+// SetSeed makes random clicks reproducible: games are seeded one after another from seed on,
+// so a single game can be played again from its GameSeed
+func (e *engine) SetSeed(seed int64) {
+	e.nextSeed = seed
 }
 
-func (e *engine) GrabScreen() image.Image {
-	img := macos.TakeScreenshot(e.windowID)
-	cropped := img.SubImage(rect(0, headerHeight, e.width*tileSize, headerHeight+e.height*tileSize+footerHeight))
-	return cropped
+// GameSeed returns the seed of random clicks in the current game
+func (e engine) GameSeed() int64 {
+	return e.gameSeed
 }
 
-func (e engine) StartGame() {
-	macos.KeyPressWithModifier(keycode.KeyN, keycode.KeyCommand)
+func (e *engine) StartGame() {
+	e.stats = Stats{}
+	e.gameSeed = e.nextSeed
+	e.nextSeed++
+	e.rng.Seed(e.gameSeed)
+	e.actuator.NewGame()
+	e.started = time.Now()
 	for y := 0; y < int(e.height); y++ {
 		for x := 0; x < int(e.width); x++ {
 			e.field[y][x] = Unknown
 		}
 	}
-}
-
-func (e engine) tileCenterX(x int) int {
-	return e.x + x*tileSize + tileSize/2
-}
-
-func (e engine) tileCenterY(y int) int {
-	return e.y + y*tileSize + tileSize/2
+	e.frontier = nil
+	if e.observer != nil {
+		e.observer.GameStarted(int(e.width), int(e.height))
+	}
 }
 
 func (e engine) LeftClick(x, y int) {
-	macos.LeftClickT(e.tileCenterX(x), e.tileCenterY(y), e.ClickDuration)
+	e.actuator.Reveal(x, y)
 }
 
 func (e engine) RightClick(x, y int) {
-	macos.RightClickT(e.tileCenterX(x), e.tileCenterY(y), e.ClickDuration)
+	e.actuator.Flag(x, y)
 }
 
 func (e engine) PrintField() {
@@ -193,166 +226,223 @@ func tilesString(tiles []Tile) string {
 	return buf.String()
 }
 
-func rect(x0, y0, x1, y1 uint) image.Rectangle {
-	return image.Rect(int(x0), int(y0), int(x1), int(y1))
+func (e *engine) UpdateField(unknownsOnly bool) error {
+	return e.reader.ReadField(e.field, unknownsOnly)
 }
 
-func (e *engine) UpdateField(unknownsOnly bool) error {
-	img := e.GrabScreen().(*image.RGBA)
-	saveImage("debug/field.png", img)
-
-	const (
-		topMargin   = 9
-		rightMargin = 20
-		squareSize  = 16
-	)
-	bombs := img.SubImage(rect(
-		e.width*tileSize-rightMargin-squareSize,
-		e.height*tileSize+headerHeight+topMargin,
-		e.width*tileSize-rightMargin,
-		e.height*tileSize+headerHeight+topMargin+squareSize,
-	))
-	e.bombCountHash = ImageHash(imghash.Average(bombs))
-	// saveImage("debug/bombs.png", bombs)
-	// log.Printf("bomb hash: %X\n", e.bombCountHash)
-
-	var x, y uint
-	var err error
-	var tileValue Tile
-	for y = 0; y < e.height; y++ {
-		for x = 0; x < e.width; x++ {
-			skip := unknownsOnly && e.field[y][x] != Unknown
-			if skip {
+// GameLoop handles game logic and communication
+func (e *engine) GameLoop() bool {
+	won := e.gameLoop()
+	e.finishStats()
+	if e.observer != nil {
+		e.observer.GameEnded(won)
+	}
+	return won
+}
+
+func (e *engine) gameLoop() bool {
+	e.readFailures = 0
+	capture := true
+	for {
+		if capture {
+			ended, won, ok := e.observe()
+			if ended {
+				return won
+			}
+			if !ok {
 				continue
 			}
-			tile := img.SubImage(rect(x*tileSize, y*tileSize+headerHeight, (x+1)*tileSize, (y+1)*tileSize+headerHeight))
-			tileValue, err = e.recognizeTile(tile)
-			if err != nil {
-				return err
+		}
+		capture = true
+		e.updateFrontier()
+		if e.open() {
+			continue
+		}
+		actions := decide(e.strategy, e.field, e.minesLeft(), e.frontier)
+		if len(actions) == 0 {
+			log.Println("🌀 Cannot decide what to do..")
+			if !e.ClickRandomUnknown() {
+				log.Println("🤗 There is no unknowns to click on..")
+				return false
 			}
-			e.field[y][x] = tileValue
+			continue
 		}
+		e.perform(actions)
+		// flags were put on the field already, only opened tiles bring anything new to see
+		capture = opensTiles(actions)
 	}
-	return nil
 }
 
-func (e engine) recognizeTile(tile image.Image) (Tile, error) {
-	hash := ImageHash(imghash.Average(tile))
-	value, ok := tileHashes[hash]
-	if !ok {
-		saveImage(fmt.Sprintf("debug/error_%X.png", hash), tile)
-		return Unknown, fmt.Errorf("Unknown hash: %X\n", hash)
+// observe captures the field and tells whether the game has ended, and whether the field can be played on
+func (e *engine) observe() (ended, won, ok bool) {
+	err := e.UpdateField(true)
+	e.stats.Captures++
+	e.flagsSinceCapture = 0
+	if err == nil && e.observer != nil {
+		e.observer.FieldRead(e.field)
 	}
-	if value == Unknown {
-		// tile is a subimage, so we need its offset
-		coords := tile.Bounds().Min
-		col := tile.(*image.RGBA).RGBAAt(coords.X+1, coords.Y+1)
-
-		avg := (uint(col.R) + uint(col.G) + uint(col.B)) / 3
-		if avg < 180 { // if it is more purple than white
-			value = Unknown
-		} else {
-			value = OpenSpace
+	state := e.gameState(err)
+	if e.reader.Cleared() && state != Lost {
+		log.Println("🤔 Should be victory but some tiles may remain")
+		// Clicking on ramaining unknowns
+		for y := 0; y < int(e.height); y++ {
+			for x := 0; x < int(e.width); x++ {
+				t := e.field[y][x]
+				if t == Unknown {
+					log.Println("Clicking on", x, y)
+					e.LeftClick(x, y)
+					e.stats.Moves++
+					e.notifyAction(Action{Kind: RevealAction, At: image.Pt(x, y), Confidence: 1, Rule: VictoryRule})
+				}
+			}
 		}
+		log.Println("🎉 Victory!")
+		return true, true, false
 	}
-	return value, nil
-}
-
-func saveImage(filename string, img image.Image) {
-	outFile, _ := os.Create(filename)
-	defer outFile.Close()
-	png.Encode(outFile, img)
+	switch state {
+	case Won:
+		log.Println("🎉 Victory!")
+		return true, true, false
+	case Lost:
+		log.Println("💣 Boom!")
+		return true, false, false
+	}
+	if err != nil {
+		e.readFailures++
+		log.Println("🙈 Cannot read the field:", err)
+		if e.readFailures >= maxReadFailures {
+			log.Println("🙈 Giving up after", e.readFailures, "unreadable fields")
+			return true, false, false
+		}
+		// unknownsOnly reads may have left tiles half updated
+		e.field = NewBoard(int(e.width), int(e.height))
+		e.frontier = nil
+		return false, false, false
+	}
+	e.readFailures = 0
+	e.PrintField()
+	if e.field.Contains(Bomb) {
+		log.Println("😱 Bombs on the field! Starting again")
+		return true, false, false
+	}
+	return false, false, true
 }
 
-// GameLoop handles game logic and communication
-func (e *engine) GameLoop() bool {
-	var x, y int
-	var didSomething bool
-	var err error
-	for {
-		didSomething = false
-		err = e.UpdateField(true)
-		if e.bombCountHash == zeroBombsHash {
-			log.Println("🤔 Should be victory but some tiles may remain")
-			// Clicking on ramaining unknowns
-			for y = 0; y < int(e.height); y++ {
-				for x = 0; x < int(e.width); x++ {
-					t := e.field[y][x]
-					if t == Unknown {
-						log.Println("Clicking on", x, y)
-						e.LeftClick(x, y)
-					}
+// updateFrontier finds tiles that are no longer unknown and updates the frontier around them,
+// so strategies only revisit what changed instead of the whole field
+func (e *engine) updateFrontier() {
+	width := int(e.width)
+	if e.frontier == nil {
+		e.frontier = NewFrontier(e.field)
+		e.unknowns = e.unknowns[:0]
+		for y := range e.field {
+			for x, tile := range e.field[y] {
+				if tile == Unknown {
+					e.unknowns = append(e.unknowns, y*width+x)
 				}
 			}
-			log.Println("🎉 Victory!")
+		}
+		return
+	}
+	var changed []image.Point
+	unknowns := e.unknowns[:0]
+	for _, i := range e.unknowns {
+		x, y := i%width, i/width
+		if e.field[y][x] == Unknown {
+			unknowns = append(unknowns, i)
+		} else {
+			changed = append(changed, image.Pt(x, y))
+		}
+	}
+	e.unknowns = unknowns
+	e.frontier.Update(e.field, changed)
+}
+
+// opensTiles reports that some of the actions reveal tiles
+func opensTiles(actions []Action) bool {
+	for _, a := range actions {
+		if a.Kind != FlagAction {
 			return true
-		} else if err != nil {
-			// log.Fatal(err) // Assume that we know all useful hashes
-			log.Println("💣 Boom!")
-			return false
 		}
-		e.PrintField()
-		for y = 0; y < int(e.height) && !didSomething; y++ {
-			for x = 0; x < int(e.width) && !didSomething; x++ {
-				didSomething, err = e.processTile(x, y)
-				if err != nil {
-					log.Println(err)
-					return false
-				}
-			}
+	}
+	return false
+}
+
+// perform executes moves decided by the strategy
+func (e *engine) perform(actions []Action) {
+	e.verify(actions)
+	for _, a := range actions {
+		c := a.At
+		e.stats.Moves++
+		if a.Confidence < 1 {
+			e.stats.Guesses++
 		}
-		if !didSomething {
-			log.Println("🌀 Cannot decide what to do..")
-			if !e.ClickRandomUnknown() {
-				log.Println("🤗 There is no unknowns to click on..")
-				return false
+		switch a.Kind {
+		case FlagAction:
+			e.field[c.Y][c.X] = Flag
+			e.flagsSinceCapture++
+			log.Println(a.Rule, "Setting flag at", c.X, c.Y)
+			e.RightClick(c.X, c.Y)
+		case RevealAction:
+			if a.Confidence < 1 {
+				log.Printf("%s Clicking on %d %d, mine chance %.1f%%\n", a.Rule, c.X, c.Y, (1-a.Confidence)*100)
+			} else {
+				log.Println(a.Rule, "Clicking on", c.X, c.Y)
 			}
+			e.LeftClick(c.X, c.Y)
+		case ChordAction:
+			log.Println(a.Rule, "Chording on", c.X, c.Y)
+			e.chord(c.X, c.Y)
 		}
+		e.notifyAction(a)
 	}
 }
 
-func (e *engine) processTile(x, y int) (bool, error) {
-	tile := e.field[y][x]
-	if tile == Bomb {
-		return false, errors.New("😱 Bombs on the field! Starting again")
+// verify checks certain moves against the SAT oracle when validation is on and reports unproven ones
+func (e *engine) verify(actions []Action) {
+	if !e.validate {
+		return
 	}
-	// log.Println(x, y, tile)
-	if tile < 1 || tile > 8 {
-		return false, nil
-	}
-	_, coords, unknownCount, flagCount := e.getNeighbours(x, y)
-	// log.Println(tilesString(tiles))
-	// Marking flags
-	var flagged bool
-	if unknownCount == int(tile)-flagCount {
-		for k := 0; k < len(coords); k++ {
-			c := coords[k]
-			t := e.field[c.Y][c.X]
-			if t == Unknown {
-				e.field[c.Y][c.X] = Flag
-				flagCount++
-				log.Println("Setting flag at", c.X, c.Y)
-				e.RightClick(c.X, c.Y)
-				flagged = true
+	var safe, mines []image.Point
+	rules := map[image.Point]string{}
+	for _, a := range actions {
+		if a.Confidence < 1 {
+			continue
+		}
+		switch a.Kind {
+		case FlagAction:
+			mines = append(mines, a.At)
+		case RevealAction:
+			safe = append(safe, a.At)
+		case ChordAction:
+			_, coords, _, _ := e.field.Neighbours(a.At.X, a.At.Y)
+			for _, c := range coords {
+				if e.field[c.Y][c.X] == Unknown {
+					safe = append(safe, c)
+					rules[c] = a.Rule
+				}
 			}
 		}
+		rules[a.At] = a.Rule
 	}
-	if flagged {
-		return true, nil
+	wrongSafe, wrongMines := e.field.VerifyMoves(safe, mines, e.minesLeft())
+	for _, c := range wrongSafe {
+		log.Println("❌", rules[c], "claims", c.X, c.Y, "is safe, but it can be a mine")
 	}
-	// Clicking on safe unknowns
-	if unknownCount > 0 && int(tile) == flagCount {
-		for k := 0; k < len(coords); k++ {
-			c := coords[k]
-			t := e.field[c.Y][c.X]
-			if t == Unknown {
-				log.Println("Clicking on", c.X, c.Y)
-				e.LeftClick(c.X, c.Y)
-				return true, nil
-			}
+	for _, c := range wrongMines {
+		log.Println("❌", rules[c], "claims", c.X, c.Y, "is a mine, but it can be safe")
+	}
+	e.mistakes += len(wrongSafe) + len(wrongMines)
+}
+
+// minesLeft asks the frontend how many mines are still not flagged, -1 if it cannot tell
+func (e *engine) minesLeft() int {
+	if counter, ok := e.reader.(MineCounter); ok {
+		if mines, ok := counter.MinesLeft(); ok {
+			return mines - e.flagsSinceCapture
 		}
 	}
-	return false, nil
+	return -1
 }
 
 func (e *engine) ClickRandomUnknown() bool {
@@ -368,15 +458,18 @@ func (e *engine) ClickRandomUnknown() bool {
 	if unknownCount == 0 {
 		return false
 	}
-	randomIndex := rand.Intn(unknownCount)
+	randomIndex := e.rng.Intn(unknownCount)
 	unknownCount = 0
 	for y := 0; y < int(e.height); y++ {
 		for x := 0; x < int(e.width); x++ {
 			tile := e.field[y][x]
 			if tile == Unknown {
 				if unknownCount == randomIndex {
-					log.Println("❗️ Randomly clicking on", x, y)
+					log.Println(RandomRule, "Randomly clicking on", x, y)
 					e.LeftClick(x, y)
+					e.stats.Moves++
+					e.stats.Guesses++
+					e.notifyAction(Action{Kind: RevealAction, At: image.Pt(x, y), Rule: RandomRule})
 					return true
 				}
 				unknownCount++
@@ -385,40 +478,3 @@ func (e *engine) ClickRandomUnknown() bool {
 	}
 	return false
 }
-
-func (e engine) getNeighbours(x0, y0 int) ([]Tile, []image.Point, int, int) {
-	var tiles = []Tile{}
-	var coords = []image.Point{}
-	var unknownCount, flagCount int
-	var tile Tile
-	for y := max(0, y0-1); y < min(int(e.height), y0+2); y++ {
-		for x := max(0, x0-1); x < min(int(e.width), x0+2); x++ {
-			if x != x0 || y != y0 {
-				tile = e.field[y][x]
-				if tile == Unknown {
-					unknownCount++
-				}
-				if tile == Flag {
-					flagCount++
-				}
-				coords = append(coords, image.Pt(x, y))
-				tiles = append(tiles, tile)
-			}
-		}
-	}
-	return tiles, coords, unknownCount, flagCount
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"image"
+	"sort"
+)
+
+// Frontier keeps track of numbered tiles having unknown neighbours, and of those of them
+// whose neighbourhood changed since strategies last looked at it. It keeps the board
+// in packed form too, membership is found from its masks.
+// Tiles are indexed y*width+x.
+type Frontier struct {
+	width, height int
+	packed        *Bitboard
+	member        []bool
+	count         int
+	dirty         []bool
+	dirtyList     []int
+}
+
+// FrontierStrategy is implemented by strategies that can work from a frontier kept up to date
+// by the engine, instead of walking the whole board on every decision
+type FrontierStrategy interface {
+	DecideFrontier(board Board, minesLeft int, frontier *Frontier) []Action
+}
+
+// NewFrontier finds the frontier of a board, with every tile of it dirty
+func NewFrontier(board Board) *Frontier {
+	f := &Frontier{
+		width:  board.Width(),
+		height: board.Height(),
+		packed: Pack(board),
+		member: make([]bool, board.Width()*board.Height()),
+		dirty:  make([]bool, board.Width()*board.Height()),
+	}
+	for _, c := range f.packed.FrontierTiles() {
+		i := c.Y*f.width + c.X
+		f.member[i] = true
+		f.count++
+		f.markDirty(i)
+	}
+	return f
+}
+
+// Update accounts for changed tiles of the board, marking numbered tiles around them dirty
+func (f *Frontier) Update(board Board, changed []image.Point) {
+	for _, c := range changed {
+		f.packed.Set(c.X, c.Y, board[c.Y][c.X])
+	}
+	for _, c := range changed {
+		r := f.packed.around(c.X, c.Y)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				f.refresh(x, y)
+			}
+		}
+	}
+}
+
+// Packed returns the board as the frontier last saw it, in packed form
+func (f *Frontier) Packed() *Bitboard {
+	return f.packed
+}
+
+// refresh updates frontier membership of a tile, marking it dirty when it is on the frontier
+func (f *Frontier) refresh(x, y int) {
+	i := y*f.width + x
+	onFrontier := false
+	if f.packed.number(x, y) != 0 {
+		unknownCount, _ := f.packed.Counts(x, y)
+		onFrontier = unknownCount > 0
+	}
+	if onFrontier != f.member[i] {
+		f.member[i] = onFrontier
+		if onFrontier {
+			f.count++
+		} else {
+			f.count--
+		}
+	}
+	if onFrontier {
+		f.markDirty(i)
+	}
+}
+
+func (f *Frontier) markDirty(i int) {
+	if !f.dirty[i] {
+		f.dirty[i] = true
+		f.dirtyList = append(f.dirtyList, i)
+	}
+}
+
+// Len returns the number of frontier tiles
+func (f *Frontier) Len() int {
+	return f.count
+}
+
+// Tiles returns frontier tiles in reading order
+func (f *Frontier) Tiles() []image.Point {
+	tiles := make([]image.Point, 0, f.count)
+	for i, member := range f.member {
+		if member {
+			tiles = append(tiles, image.Pt(i%f.width, i/f.width))
+		}
+	}
+	return tiles
+}
+
+// TakeDirty returns dirty frontier tiles in reading order and marks them clean
+func (f *Frontier) TakeDirty() []image.Point {
+	sort.Ints(f.dirtyList)
+	tiles := make([]image.Point, 0, len(f.dirtyList))
+	for _, i := range f.dirtyList {
+		f.dirty[i] = false
+		if f.member[i] {
+			tiles = append(tiles, image.Pt(i%f.width, i/f.width))
+		}
+	}
+	f.dirtyList = f.dirtyList[:0]
+	return tiles
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"image"
+	"math"
+)
+
+// pivotEpsilon treats smaller coefficients as zero during elimination
+const pivotEpsilon = 1e-9
+
+// LinearDeduction solves the frontier as a 0/1 linear system: one equation per numbered tile
+// over its unknown neighbours, plus the global mine count when it is known (minesLeft >= 0).
+// After reducing the system to row echelon form every row is checked against its bounds:
+// when the right side equals the sum of negative coefficients all positive variables are safe
+// and negative ones are mines, and vice versa for the sum of positive coefficients.
+func (b Board) LinearDeduction(minesLeft int) (safe, mines []image.Point) {
+	width := b.Width()
+	cs := b.constraints()
+	if minesLeft >= 0 {
+		var all []int
+		for y := range b {
+			for x, tile := range b[y] {
+				if tile == Unknown {
+					all = append(all, y*width+x)
+				}
+			}
+		}
+		if len(all) > 0 {
+			cs = append(cs, constraint{cells: all, mines: minesLeft})
+		}
+	}
+
+	// columns are unknown cells, the last one is the right side
+	column := map[int]int{}
+	var cells []int
+	for _, c := range cs {
+		for _, cell := range c.cells {
+			if _, ok := column[cell]; !ok {
+				column[cell] = len(cells)
+				cells = append(cells, cell)
+			}
+		}
+	}
+	n := len(cells)
+	matrix := make([][]float64, len(cs))
+	for i, c := range cs {
+		matrix[i] = make([]float64, n+1)
+		for _, cell := range c.cells {
+			matrix[i][column[cell]] = 1
+		}
+		matrix[i][n] = float64(c.mines)
+	}
+	reduce(matrix, n)
+
+	d := newDeduction()
+	for _, row := range matrix {
+		safeColumns, mineColumns := rowBounds(row, n)
+		for _, j := range safeColumns {
+			d.safe[cells[j]] = true
+		}
+		for _, j := range mineColumns {
+			d.mines[cells[j]] = true
+		}
+	}
+	return points(d.safe, width), points(d.mines, width)
+}
+
+// rowBounds checks a reduced row against the bounds of its left side over 0/1 variables:
+// at the sum of negative coefficients every positive variable is 0 and every negative one is 1,
+// at the sum of positive coefficients the other way round. Anything in between proves nothing.
+func rowBounds(row []float64, n int) (zero, one []int) {
+	var lo, hi float64
+	for j := 0; j < n; j++ {
+		switch {
+		case row[j] > pivotEpsilon:
+			hi += row[j]
+		case row[j] < -pivotEpsilon:
+			lo += row[j]
+		}
+	}
+	rhs := row[n]
+	if lo == hi {
+		return nil, nil
+	}
+	for j := 0; j < n; j++ {
+		switch {
+		case math.Abs(row[j]) < pivotEpsilon:
+		case math.Abs(rhs-lo) < pivotEpsilon && row[j] > 0,
+			math.Abs(rhs-hi) < pivotEpsilon && row[j] < 0:
+			zero = append(zero, j)
+		case math.Abs(rhs-lo) < pivotEpsilon && row[j] < 0,
+			math.Abs(rhs-hi) < pivotEpsilon && row[j] > 0:
+			one = append(one, j)
+		}
+	}
+	return zero, one
+}
+
+// reduce brings the first n columns of a matrix to reduced row echelon form in place
+func reduce(matrix [][]float64, n int) {
+	row := 0
+	for col := 0; col < n && row < len(matrix); col++ {
+		pivot := row
+		for i := row + 1; i < len(matrix); i++ {
+			if math.Abs(matrix[i][col]) > math.Abs(matrix[pivot][col]) {
+				pivot = i
+			}
+		}
+		if math.Abs(matrix[pivot][col]) < pivotEpsilon {
+			continue
+		}
+		matrix[row], matrix[pivot] = matrix[pivot], matrix[row]
+		scale := matrix[row][col]
+		for j := col; j <= n; j++ {
+			matrix[row][j] /= scale
+		}
+		for i := range matrix {
+			if i == row || math.Abs(matrix[i][col]) < pivotEpsilon {
+				continue
+			}
+			factor := matrix[i][col]
+			for j := col; j <= n; j++ {
+				matrix[i][j] -= factor * matrix[row][j]
+			}
+		}
+		row++
+	}
+}
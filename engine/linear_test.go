@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"image"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// randomBoard lays mines at random and reveals the numbers of some safe tiles
+func randomBoard(rng *rand.Rand, width, height, mines int) Board {
+	mined := map[int]bool{}
+	for _, i := range rng.Perm(width * height)[:mines] {
+		mined[i] = true
+	}
+	b := NewBoard(width, height)
+	for y := range b {
+		for x := range b[y] {
+			if mined[y*width+x] || rng.Intn(2) == 0 {
+				continue
+			}
+			count := 0
+			r := b.around(x, y)
+			for ny := r.Min.Y; ny < r.Max.Y; ny++ {
+				for nx := r.Min.X; nx < r.Max.X; nx++ {
+					if mined[ny*width+nx] {
+						count++
+					}
+				}
+			}
+			b[y][x] = Tile(count)
+			if count == 0 {
+				b[y][x] = OpenSpace
+			}
+		}
+	}
+	return b
+}
+
+func TestLinearDeductionBeyondSubsets(t *testing.T) {
+	cases := []struct {
+		rows        []string
+		safe, mines []image.Point
+	}{
+		{[]string{
+			"???1",
+			"1?2?",
+		}, []image.Point{{0, 0}}, nil},
+		{[]string{
+			"1??2",
+			"?2??",
+			"??2?",
+		}, []image.Point{{0, 2}, {1, 2}}, []image.Point{{3, 1}}},
+		{[]string{
+			"?2?1?",
+			"1????",
+		}, []image.Point{{4, 0}, {3, 1}, {4, 1}}, nil},
+	}
+	for _, c := range cases {
+		b := parse(t, c.rows...)
+		if safe, mines := b.SubsetDeduction(); len(safe) != 0 || len(mines) != 0 {
+			t.Errorf("%v: subset rules already find %v, %v", c.rows, safe, mines)
+		}
+		safe, mines := b.LinearDeduction(-1)
+		if len(mines) == 0 {
+			mines = nil
+		}
+		if !reflect.DeepEqual(safe, c.safe) || !reflect.DeepEqual(mines, c.mines) {
+			t.Errorf("%v: safe %v, mines %v, want %v, %v", c.rows, safe, mines, c.safe, c.mines)
+		}
+	}
+}
+
+func TestLinearDeductionUsesMineCount(t *testing.T) {
+	b := parse(t, "?1???")
+	if safe, mines := b.LinearDeduction(-1); len(safe) != 0 || len(mines) != 0 {
+		t.Errorf("without the mine count: safe %v, mines %v", safe, mines)
+	}
+	// the one mine is next to the 1, the rest is safe
+	safe, mines := b.LinearDeduction(1)
+	if !reflect.DeepEqual(safe, []image.Point{{3, 0}, {4, 0}}) || len(mines) != 0 {
+		t.Errorf("one mine left: safe %v, mines %v", safe, mines)
+	}
+	// every tile away from the 1 is a mine
+	safe, mines = b.LinearDeduction(3)
+	if len(safe) != 0 || !reflect.DeepEqual(mines, []image.Point{{3, 0}, {4, 0}}) {
+		t.Errorf("three mines left: safe %v, mines %v", safe, mines)
+	}
+}
+
+// Elimination divides by pivots, leaving rows with fractions like 1/3 or 2/3.
+// Every move claimed on random boards has to hold in every layout, so rounding
+// never makes a fractional row look like one at its bound.
+func TestLinearDeductionIsSound(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	claims := 0
+	for i := 0; i < 1000; i++ {
+		mines := 3 + rng.Intn(4)
+		b := randomBoard(rng, 5, 4, mines)
+		unknowns := 0
+		for y := range b {
+			for _, tile := range b[y] {
+				if tile == Unknown {
+					unknowns++
+				}
+			}
+		}
+		for _, minesLeft := range []int{-1, mines} {
+			if minesLeft >= 0 && unknowns > 16 {
+				continue
+			}
+			p, ok := bruteForce(b, minesLeft)
+			if !ok {
+				t.Fatalf("%v: no layout with %d mines", b.Rows(), minesLeft)
+			}
+			safe, mined := b.LinearDeduction(minesLeft)
+			for _, c := range safe {
+				claims++
+				if p[c] != 0 {
+					t.Errorf("%v with %d mines: %v claimed safe, mine with %v", b.Rows(), minesLeft, c, p[c])
+				}
+			}
+			for _, c := range mined {
+				claims++
+				if p[c] != 1 {
+					t.Errorf("%v with %d mines: %v claimed a mine, mine with %v", b.Rows(), minesLeft, c, p[c])
+				}
+			}
+		}
+	}
+	if claims == 0 {
+		t.Error("no moves claimed at all")
+	}
+}
+
+func TestFractionalRowsProveNothing(t *testing.T) {
+	third := 1.0 / 3
+	cases := []struct {
+		row       []float64
+		zero, one []int
+	}{
+		// a third of a mine over three tiles, as left by dividing by a pivot of 3
+		{[]float64{third, third, third, third}, nil, nil},
+		{[]float64{1, third, -third, 2 * third}, nil, nil},
+		// off the bound by more than rounding
+		{[]float64{1, 1, 0, 2 - 1e-6}, nil, nil},
+		{[]float64{1, -1, 0, 1e-6}, nil, nil},
+		// at the bound up to rounding
+		{[]float64{third, third, third, 1 - 1e-12}, nil, []int{0, 1, 2}},
+		{[]float64{1, -third, 0, -third + 1e-12}, []int{0}, []int{1}},
+		{[]float64{1, 1, 0, 1e-12}, []int{0, 1}, nil},
+	}
+	for _, c := range cases {
+		zero, one := rowBounds(c.row, len(c.row)-1)
+		if !reflect.DeepEqual(zero, c.zero) || !reflect.DeepEqual(one, c.one) {
+			t.Errorf("row %v: zero %v, one %v, want %v, %v", c.row, zero, one, c.zero, c.one)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package engine
+
+// Observer is told about everything happening in a game, e.g. to record it
+type Observer interface {
+	GameStarted(width, height int)
+	FieldRead(field Board)
+	ActionPerformed(action Action)
+	GameEnded(won bool)
+}
+
+// Rules of moves not coming from a strategy
+const (
+	RandomRule  = "❗️"
+	VictoryRule = "🎉"
+	OpeningRule = "🚪"
+)
+
+func (e *engine) SetObserver(observer Observer) {
+	e.observer = observer
+}
+
+func (e *engine) notifyAction(action Action) {
+	if e.observer != nil {
+		e.observer.ActionPerformed(action)
+	}
+}
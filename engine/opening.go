@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"sort"
+)
+
+// FirstClickRule tells what a game guarantees about the first click
+type FirstClickRule int
+
+// Known first click rules
+const (
+	SafeTile    FirstClickRule = iota // the clicked tile is never a mine
+	SafeOpening                       // neither the clicked tile nor its neighbours are mines, so it always opens
+	AnyTile                           // no guarantee, the first click can hit a mine
+)
+
+var firstClickNames = []string{"safe", "opening", "any"}
+
+func (r FirstClickRule) String() string {
+	if int(r) < len(firstClickNames) {
+		return firstClickNames[r]
+	}
+	return fmt.Sprintf("rule(%d)", int(r))
+}
+
+// ParseFirstClickRule reads a first click rule from its name
+func ParseFirstClickRule(name string) (FirstClickRule, error) {
+	for r, n := range firstClickNames {
+		if n == name {
+			return FirstClickRule(r), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown first click rule: %s", name)
+}
+
+// OpeningPolicy picks the first tile to click on a fresh board, mines is -1 when unknown
+type OpeningPolicy interface {
+	Opening(width, height, mines int) image.Point
+}
+
+// OpeningFunc adapts a plain function to the OpeningPolicy interface
+type OpeningFunc func(width, height, mines int) image.Point
+
+// Opening calls the function
+func (f OpeningFunc) Opening(width, height, mines int) image.Point {
+	return f(width, height, mines)
+}
+
+// Fixed opening policies: a corner tile has the fewest neighbours to be mines,
+// an edge or centre tile opens a larger area when it is a zero
+var (
+	CornerOpening OpeningPolicy = OpeningFunc(func(width, height, _ int) image.Point {
+		return image.Pt(0, 0)
+	})
+	EdgeOpening OpeningPolicy = OpeningFunc(func(width, height, _ int) image.Point {
+		return image.Pt(width/2, 0)
+	})
+	CentreOpening OpeningPolicy = OpeningFunc(func(width, height, _ int) image.Point {
+		return image.Pt(width/2, height/2)
+	})
+)
+
+var openings = map[string]OpeningPolicy{
+	"corner": CornerOpening,
+	"edge":   EdgeOpening,
+	"centre": CentreOpening,
+}
+
+// Openings returns names of fixed opening policies
+func Openings() []string {
+	names := make([]string, 0, len(openings))
+	for name := range openings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseOpening finds a fixed opening policy by its name
+func ParseOpening(name string) (OpeningPolicy, error) {
+	if policy, ok := openings[name]; ok {
+		return policy, nil
+	}
+	return nil, fmt.Errorf("Unknown opening: %s", name)
+}
+
+// OpeningTableVersion is the version of opening table files written by this code
+const OpeningTableVersion = 1
+
+// OpeningTable holds learned openings by board size and first click rule, as in "9x9/10 safe"
+type OpeningTable map[string]image.Point
+
+type openingTableFile struct {
+	Version  int          `json:"version"`
+	Openings OpeningTable `json:"openings"`
+}
+
+// LoadOpeningTable reads an opening table file
+func LoadOpeningTable(path string) (OpeningTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var f openingTableFile
+	if err := json.NewDecoder(file).Decode(&f); err != nil {
+		return nil, err
+	}
+	if f.Version != OpeningTableVersion {
+		return nil, fmt.Errorf("Opening table version %d is not supported, expected %d", f.Version, OpeningTableVersion)
+	}
+	if f.Openings == nil {
+		f.Openings = OpeningTable{}
+	}
+	return f.Openings, nil
+}
+
+// Save writes the opening table file
+func (t OpeningTable) Save(path string) error {
+	data, err := json.MarshalIndent(openingTableFile{OpeningTableVersion, t}, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LearnedOpening picks the tile that Learn finds best for a board size, learning every size once.
+// Boards with an unknown number of mines are opened in a corner.
+type LearnedOpening struct {
+	Learn func(width, height, mines int) image.Point
+	// Rule is the first click rule Learn plays by, openings learned for other rules are not reused
+	Rule FirstClickRule
+	// Table holds openings learned so far, it can be loaded from a previous run
+	Table OpeningTable
+	// Learned is called after a new size is learned, to keep the table
+	Learned func(OpeningTable)
+	warned  bool
+}
+
+// Opening implements OpeningPolicy
+func (l *LearnedOpening) Opening(width, height, mines int) image.Point {
+	if mines < 0 {
+		if !l.warned {
+			l.warned = true
+			log.Println("🚪 Mine count unknown, opening in a corner instead of a learned tile")
+		}
+		return CornerOpening.Opening(width, height, mines)
+	}
+	size := fmt.Sprintf("%dx%d/%d %s", width, height, mines, l.Rule)
+	if at, ok := l.Table[size]; ok {
+		return at
+	}
+	if l.Table == nil {
+		l.Table = OpeningTable{}
+	}
+	at := l.Learn(width, height, mines)
+	l.Table[size] = at
+	if l.Learned != nil {
+		l.Learned(l.Table)
+	}
+	return at
+}
+
+// OpeningProbability returns the chance that the first click on a tile is a zero, opening an area
+func OpeningProbability(width, height, mines int, at image.Point, rule FirstClickRule) float64 {
+	tiles := width * height
+	around := (min(width, at.X+2)-max(0, at.X-1))*(min(height, at.Y+2)-max(0, at.Y-1)) - 1
+	free := tiles - 1 - around
+	switch rule {
+	case SafeOpening:
+		if mines <= free {
+			return 1
+		}
+		// games fall back to a safe tile when mines do not fit elsewhere
+		return math.Exp(logChoose(free, mines) - logChoose(tiles-1, mines))
+	case AnyTile:
+		return math.Exp(logChoose(free, mines) - logChoose(tiles, mines))
+	}
+	return math.Exp(logChoose(free, mines) - logChoose(tiles-1, mines))
+}
+
+// SetOpening makes the engine start games on a tile chosen by a policy, nil leaves it to the strategy
+func (e *engine) SetOpening(policy OpeningPolicy, rule FirstClickRule) {
+	e.opening = policy
+	e.firstClick = rule
+}
+
+// open makes the first click of a game when there is an opening policy and the field is untouched
+func (e *engine) open() bool {
+	if e.opening == nil || e.stats.Moves > 0 || len(e.unknowns) != int(e.width*e.height) {
+		return false
+	}
+	width, height, mines := int(e.width), int(e.height), e.minesLeft()
+	at := e.opening.Opening(width, height, mines)
+	confidence := 1.0
+	if e.firstClick == AnyTile && mines >= 0 {
+		confidence = 1 - float64(mines)/float64(width*height)
+	}
+	if mines >= 0 {
+		log.Printf("%s Opening at %d %d, zero chance %.1f%%\n", OpeningRule, at.X, at.Y,
+			OpeningProbability(width, height, mines, at, e.firstClick)*100)
+	} else {
+		log.Println(OpeningRule, "Opening at", at.X, at.Y)
+	}
+	e.LeftClick(at.X, at.Y)
+	e.stats.Moves++
+	if confidence < 1 {
+		e.stats.Guesses++
+	}
+	e.notifyAction(Action{Kind: RevealAction, At: at, Confidence: confidence, Rule: OpeningRule})
+	return true
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLearnedOpeningKeepsTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "openings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "openings.json")
+
+	learnt := 0
+	learn := func(width, height, mines int) image.Point {
+		learnt++
+		return image.Pt(width/2, 1)
+	}
+	saved := 0
+	opening := &LearnedOpening{Learn: learn, Rule: SafeTile, Learned: func(table OpeningTable) {
+		saved++
+		if err := table.Save(path); err != nil {
+			t.Fatal(err)
+		}
+	}}
+	for i := 0; i < 3; i++ {
+		if at := opening.Opening(9, 9, 10); at != image.Pt(4, 1) {
+			t.Errorf("opening at %v", at)
+		}
+	}
+	opening.Opening(30, 16, 99)
+	if learnt != 2 || saved != 2 {
+		t.Errorf("learned %d and saved %d times, want each size once", learnt, saved)
+	}
+
+	table, err := LoadOpeningTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(table, opening.Table) {
+		t.Errorf("loaded %v, saved %v", table, opening.Table)
+	}
+	// a later run starts from the file
+	learnt = 0
+	next := &LearnedOpening{Learn: learn, Rule: SafeTile, Table: table}
+	next.Opening(9, 9, 10)
+	next.Opening(30, 16, 99)
+	if learnt != 0 {
+		t.Errorf("learned %d sizes again", learnt)
+	}
+	// another first click rule has openings of its own
+	other := &LearnedOpening{Learn: learn, Rule: SafeOpening, Table: table}
+	other.Opening(9, 9, 10)
+	if learnt != 1 {
+		t.Errorf("opening learned for %s reused for %s", SafeTile, SafeOpening)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"version": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOpeningTable(path); err == nil {
+		t.Error("loaded a table of another version")
+	}
+}
+
+func TestLearnedOpeningWithoutMineCount(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	opening := &LearnedOpening{Learn: func(width, height, mines int) image.Point {
+		t.Error("learning without a mine count")
+		return image.Pt(1, 1)
+	}}
+	for i := 0; i < 3; i++ {
+		if at := opening.Opening(9, 9, -1); at != image.Pt(0, 0) {
+			t.Errorf("opening at %v, want the corner", at)
+		}
+	}
+	if n := strings.Count(out.String(), "corner"); n != 1 {
+		t.Errorf("fallback logged %d times, want once:\n%s", n, out.String())
+	}
+}
@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"image"
+	"log"
+
+	"../sat"
+)
+
+// maxGlobalCells limits the number of frontier tiles for which the mine count joins the CNF,
+// beyond that the counter encoding outgrows the benefit
+const maxGlobalCells = 128
+
+// globalCountWarned is set once the mine count has been left out, so it is only logged once
+var globalCountWarned bool
+
+// oracle answers whether a tile can be a mine or can be safe under the visible field
+type oracle struct {
+	formula sat.Formula
+	vars    map[int]sat.Literal
+	cells   []int
+	width   int
+	// numbers around numbered tiles, in frontier literals
+	numbers []numberConstraint
+	// minesLeft is negative when unknown, interior counts unknown tiles off the frontier
+	minesLeft, interior int
+	// values seen for each variable in models found so far
+	seenMine, seenSafe map[int]bool
+	// answers for tiles off the frontier, all of them alike: 0 when not asked yet, 1 yes, -1 no
+	interiorMine, interiorSafe int8
+}
+
+type numberConstraint struct {
+	mines int
+	lits  []sat.Literal
+}
+
+// newOracle encodes the field as CNF: exactly n-flags mines around every numbered tile,
+// plus the global mine count when it is known. Tiles off the frontier are interchangeable,
+// so they only enter as their number: the frontier holds between minesLeft minus the interior
+// tiles and minesLeft mines.
+func (b Board) newOracle(minesLeft int) *oracle {
+	o := &oracle{
+		vars:      map[int]sat.Literal{},
+		width:     b.Width(),
+		minesLeft: minesLeft,
+		seenMine:  map[int]bool{},
+		seenSafe:  map[int]bool{},
+	}
+	variable := func(cell int) sat.Literal {
+		v, ok := o.vars[cell]
+		if !ok {
+			v = sat.Literal(len(o.cells) + 1)
+			o.vars[cell] = v
+			o.cells = append(o.cells, cell)
+		}
+		return v
+	}
+	for _, c := range b.constraints() {
+		lits := make([]sat.Literal, len(c.cells))
+		for i, cell := range c.cells {
+			lits[i] = variable(cell)
+		}
+		o.numbers = append(o.numbers, numberConstraint{c.mines, lits})
+	}
+	for y := range b {
+		for x, tile := range b[y] {
+			if _, ok := o.vars[y*o.width+x]; tile == Unknown && !ok {
+				o.interior++
+			}
+		}
+	}
+	switch {
+	case minesLeft < 0:
+		o.encode(&o.formula, 0, len(o.cells))
+	case len(o.cells) > maxGlobalCells:
+		if !globalCountWarned {
+			globalCountWarned = true
+			log.Printf("🔬 %d frontier tiles, solving without the mine count", len(o.cells))
+		}
+		o.encode(&o.formula, 0, len(o.cells))
+	default:
+		o.encode(&o.formula, minesLeft-o.interior, minesLeft)
+	}
+	return o
+}
+
+// encode puts the numbers into a formula, with between least and most mines on the frontier
+func (o *oracle) encode(f *sat.Formula, least, most int) {
+	for range o.cells {
+		f.NewVar()
+	}
+	for _, c := range o.numbers {
+		f.Exactly(c.mines, c.lits)
+	}
+	frontier := make([]sat.Literal, len(o.cells))
+	negated := make([]sat.Literal, len(o.cells))
+	for i, cell := range o.cells {
+		frontier[i], negated[i] = o.vars[cell], -o.vars[cell]
+	}
+	f.AtMost(most, frontier)
+	if least > 0 {
+		f.AtMost(len(frontier)-least, negated)
+	}
+}
+
+// solve checks satisfiability under assumptions and remembers values of the model
+func (o *oracle) solve(assumptions ...sat.Literal) bool {
+	model, ok := o.formula.Solve(assumptions...)
+	if !ok {
+		return false
+	}
+	for cell, v := range o.vars {
+		if model[v] {
+			o.seenMine[cell] = true
+		} else {
+			o.seenSafe[cell] = true
+		}
+	}
+	return true
+}
+
+// interiorCan tells whether the frontier can hold between least and most mines,
+// leaving the rest of the mine count to tiles off the frontier
+func (o *oracle) interiorCan(answer *int8, least, most int) bool {
+	if *answer == 0 {
+		var f sat.Formula
+		o.encode(&f, least, most)
+		*answer = -1
+		if _, ok := f.Solve(); ok {
+			*answer = 1
+		}
+	}
+	return *answer > 0
+}
+
+func (o *oracle) canBeMine(cell int) bool {
+	v, ok := o.vars[cell]
+	if !ok {
+		// some layout leaves a mine for the interior
+		return o.minesLeft < 0 || o.interiorCan(&o.interiorMine, o.minesLeft-o.interior, o.minesLeft-1)
+	}
+	return o.seenMine[cell] || o.solve(v)
+}
+
+func (o *oracle) canBeSafe(cell int) bool {
+	v, ok := o.vars[cell]
+	if !ok {
+		// some layout leaves an interior tile free
+		return o.minesLeft < 0 || o.interiorCan(&o.interiorSafe, o.minesLeft-o.interior+1, o.minesLeft)
+	}
+	return o.seenSafe[cell] || o.solve(-v)
+}
+
+// SATDeduction tests every frontier tile with a SAT solver: a tile that cannot be a mine is safe,
+// a tile that cannot be safe is a mine. Models found on the way rule out most of the queries.
+func (b Board) SATDeduction(minesLeft int) (safe, mines []image.Point) {
+	o := b.newOracle(minesLeft)
+	if !o.solve() {
+		return nil, nil
+	}
+	d := newDeduction()
+	for _, cell := range o.cells {
+		if !o.canBeMine(cell) {
+			d.safe[cell] = true
+		} else if !o.canBeSafe(cell) {
+			d.mines[cell] = true
+		}
+	}
+	return points(d.safe, o.width), points(d.mines, o.width)
+}
+
+// VerifyMoves checks moves against the SAT oracle and returns those not proven by the field
+func (b Board) VerifyMoves(safe, mines []image.Point, minesLeft int) (wrongSafe, wrongMines []image.Point) {
+	o := b.newOracle(minesLeft)
+	for _, c := range safe {
+		if o.canBeMine(c.Y*o.width + c.X) {
+			wrongSafe = append(wrongSafe, c)
+		}
+	}
+	for _, c := range mines {
+		if o.canBeSafe(c.Y*o.width + c.X) {
+			wrongMines = append(wrongMines, c)
+		}
+	}
+	return
+}
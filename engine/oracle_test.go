@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// countBoard has a frontier of three tiles holding either the middle mine or the two outer ones,
+// and a walled-off interior larger than maxGlobalCells that only the mine count reaches
+func countBoard(t *testing.T) (Board, int) {
+	rows := []string{"?4?4?", "FFFFF"}
+	const interiorRows = 30
+	for i := 0; i < interiorRows; i++ {
+		rows = append(rows, "?????")
+	}
+	b, err := ParseBoard(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, interiorRows * 5
+}
+
+func TestSATDeductionUsesMineCountWithLargeInterior(t *testing.T) {
+	b, interior := countBoard(t)
+	left, middle, right := image.Pt(0, 0), image.Pt(2, 0), image.Pt(4, 0)
+
+	safe, mines := b.SATDeduction(1)
+	if !reflect.DeepEqual(safe, []image.Point{left, right}) || !reflect.DeepEqual(mines, []image.Point{middle}) {
+		t.Errorf("one mine left: safe %v, mines %v", safe, mines)
+	}
+	safe, mines = b.SATDeduction(interior + 2)
+	if !reflect.DeepEqual(safe, []image.Point{middle}) || !reflect.DeepEqual(mines, []image.Point{left, right}) {
+		t.Errorf("interior full: safe %v, mines %v", safe, mines)
+	}
+	safe, mines = b.SATDeduction(-1)
+	if len(safe) != 0 || len(mines) != 0 {
+		t.Errorf("unknown mine count: safe %v, mines %v", safe, mines)
+	}
+}
+
+func TestVerifyMovesWithMineCount(t *testing.T) {
+	b, _ := countBoard(t)
+	wrongSafe, wrongMines := b.VerifyMoves([]image.Point{{0, 0}}, []image.Point{{2, 0}}, 1)
+	if len(wrongSafe) != 0 || len(wrongMines) != 0 {
+		t.Errorf("proven moves rejected: %v, %v", wrongSafe, wrongMines)
+	}
+	wrongSafe, wrongMines = b.VerifyMoves([]image.Point{{0, 0}}, []image.Point{{2, 0}}, 3)
+	if len(wrongSafe) != 1 || len(wrongMines) != 1 {
+		t.Errorf("unproven moves accepted: %v, %v", wrongSafe, wrongMines)
+	}
+}
+
+func TestVerifyMovesOffFrontier(t *testing.T) {
+	boards := [][]string{
+		{"?1???"},
+		{"1?", "??"},
+		{"??1..", "??21.", "???1.", "?????"},
+		{"?????", "F...F", "1...1"},
+	}
+	for _, rows := range boards {
+		b := parse(t, rows...)
+		for minesLeft := -1; minesLeft <= 6; minesLeft++ {
+			want, ok := bruteForce(b, minesLeft)
+			if !ok {
+				continue
+			}
+			for y := range b {
+				for x, tile := range b[y] {
+					c := image.Pt(x, y)
+					if tile != Unknown {
+						continue
+					}
+					p, known := want[c]
+					wrongSafe, wrongMines := b.VerifyMoves([]image.Point{c}, []image.Point{c}, minesLeft)
+					if safe := known && p == 0; safe != (len(wrongSafe) == 0) {
+						t.Errorf("%v with %d mines: %v claimed safe, rejected %v", rows, minesLeft, c, wrongSafe)
+					}
+					if mine := known && p == 1; mine != (len(wrongMines) == 0) {
+						t.Errorf("%v with %d mines: %v claimed a mine, rejected %v", rows, minesLeft, c, wrongMines)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestLargeFrontierLoggedOnce(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+	globalCountWarned = false
+
+	width := maxGlobalCells + 10
+	b := parse(t, strings.Repeat("?", width), strings.Repeat("1", width))
+	for i := 0; i < 3; i++ {
+		b.VerifyMoves(nil, nil, width/3)
+	}
+	if n := strings.Count(out.String(), "solving without the mine count"); n != 1 {
+		t.Errorf("left out mine count logged %d times, want once", n)
+	}
+}
@@ -0,0 +1,313 @@
+package engine
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sort"
+)
+
+// MineCounter is implemented by frontends that know how many mines are not flagged yet,
+// as shown on the last field read
+type MineCounter interface {
+	MinesLeft() (int, bool)
+}
+
+// certaintyMargin absorbs float rounding when a probability should be exactly one
+const certaintyMargin = 1e-9
+
+// maxSearchNodes limits backtracking over a single frontier component
+const maxSearchNodes = 1 << 22
+
+var errSearchTooLarge = errors.New("Frontier is too large to enumerate")
+
+// component is an independent part of the frontier: cells linked through shared constraints
+type component struct {
+	cells       []int
+	constraints []constraint
+	// solutions[k] counts consistent assignments with k mines,
+	// cellMines[i][k] counts those of them where cells[i] is a mine
+	solutions []float64
+	cellMines [][]float64
+}
+
+// components splits constraints into groups not sharing any cell
+func components(cs []constraint) []*component {
+	parent := map[int]int{}
+	var find func(int) int
+	find = func(c int) int {
+		p, ok := parent[c]
+		if !ok || p == c {
+			parent[c] = c
+			return c
+		}
+		root := find(p)
+		parent[c] = root
+		return root
+	}
+	for _, c := range cs {
+		for _, cell := range c.cells[1:] {
+			parent[find(cell)] = find(c.cells[0])
+		}
+	}
+
+	byRoot := map[int]*component{}
+	var result []*component
+	for _, c := range cs {
+		root := find(c.cells[0])
+		comp, ok := byRoot[root]
+		if !ok {
+			comp = &component{}
+			byRoot[root] = comp
+			result = append(result, comp)
+		}
+		comp.constraints = append(comp.constraints, c)
+	}
+	// cells follow constraints, which go in reading order, so neighbouring cells
+	// are assigned one after another and constraints close early during the search
+	for _, comp := range result {
+		seen := map[int]bool{}
+		for _, c := range comp.constraints {
+			for _, cell := range c.cells {
+				if !seen[cell] {
+					seen[cell] = true
+					comp.cells = append(comp.cells, cell)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// enumerate counts all mine assignments of the component satisfying its constraints
+func (comp *component) enumerate() error {
+	n := len(comp.cells)
+	index := make(map[int]int, n)
+	for i, cell := range comp.cells {
+		index[cell] = i
+	}
+	// for every cell, constraints it takes part in
+	touching := make([][]int, n)
+	for ci, c := range comp.constraints {
+		for _, cell := range c.cells {
+			touching[index[cell]] = append(touching[index[cell]], ci)
+		}
+	}
+	placed := make([]int, len(comp.constraints)) // mines assigned so far
+	open := make([]int, len(comp.constraints))   // cells not assigned yet
+	for ci, c := range comp.constraints {
+		open[ci] = len(c.cells)
+	}
+
+	comp.solutions = make([]float64, n+1)
+	comp.cellMines = make([][]float64, n)
+	for i := range comp.cellMines {
+		comp.cellMines[i] = make([]float64, n+1)
+	}
+	assignment := make([]bool, n)
+	var nodes int
+
+	var search func(i, mines int) bool
+	search = func(i, mines int) bool {
+		nodes++
+		if nodes > maxSearchNodes {
+			return false
+		}
+		if i == n {
+			comp.solutions[mines]++
+			for k, mine := range assignment {
+				if mine {
+					comp.cellMines[k][mines]++
+				}
+			}
+			return true
+		}
+		for _, mine := range []bool{false, true} {
+			ok := true
+			for _, ci := range touching[i] {
+				open[ci]--
+				if mine {
+					placed[ci]++
+				}
+				need := comp.constraints[ci].mines
+				if placed[ci] > need || placed[ci]+open[ci] < need {
+					ok = false
+				}
+			}
+			assignment[i] = mine
+			next := mines
+			if mine {
+				next++
+			}
+			if ok && !search(i+1, next) {
+				return false
+			}
+			for _, ci := range touching[i] {
+				open[ci]++
+				if mine {
+					placed[ci]--
+				}
+			}
+		}
+		assignment[i] = false
+		return true
+	}
+	if !search(0, 0) {
+		return errSearchTooLarge
+	}
+	return nil
+}
+
+// convolve combines mine count distributions of independent parts
+func convolve(a, b []float64) []float64 {
+	result := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		if x == 0 {
+			continue
+		}
+		for j, y := range b {
+			result[i+j] += x * y
+		}
+	}
+	return result
+}
+
+func logChoose(n, k int) float64 {
+	if k < 0 || k > n {
+		return math.Inf(-1)
+	}
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// MineProbabilities returns exact chance of each unknown tile being a mine.
+// The frontier is split into independent components, each one enumerated by backtracking,
+// and the configurations are weighted by the number of ways to place the remaining mines
+// on unknown tiles away from the frontier. When minesLeft is negative the mine count is
+// unknown: configurations are weighted equally and tiles away from the frontier are left out,
+// nothing tells how likely they are to be mines.
+func (b Board) MineProbabilities(minesLeft int) (map[image.Point]float64, error) {
+	width := b.Width()
+	comps := components(b.constraints())
+	frontier := map[int]bool{}
+	for _, comp := range comps {
+		if err := comp.enumerate(); err != nil {
+			return nil, err
+		}
+		for _, cell := range comp.cells {
+			frontier[cell] = true
+		}
+	}
+	var interior []int
+	for y := range b {
+		for x, tile := range b[y] {
+			if tile == Unknown && !frontier[y*width+x] {
+				interior = append(interior, y*width+x)
+			}
+		}
+	}
+
+	// weight of a given number of mines on the whole frontier
+	weight := func(m int) float64 {
+		if minesLeft < 0 {
+			return 1
+		}
+		return logChoose(len(interior), minesLeft-m)
+	}
+	total := []float64{1}
+	for _, comp := range comps {
+		total = convolve(total, comp.solutions)
+	}
+	// shift log-weights so the largest is zero to keep them in float range
+	top := math.Inf(-1)
+	for m := range total {
+		if total[m] > 0 && minesLeft >= 0 {
+			top = math.Max(top, weight(m))
+		}
+	}
+	if minesLeft >= 0 && math.IsInf(top, -1) {
+		return nil, errors.New("No mine layout agrees with the mine count")
+	}
+	scaled := func(m int) float64 {
+		if minesLeft < 0 {
+			return 1
+		}
+		return math.Exp(weight(m) - top)
+	}
+
+	var norm, interiorMines float64
+	for m, count := range total {
+		w := count * scaled(m)
+		norm += w
+		if minesLeft >= 0 {
+			interiorMines += w * float64(minesLeft-m)
+		}
+	}
+	if norm == 0 {
+		return nil, errors.New("No mine layout agrees with the field")
+	}
+
+	result := map[image.Point]float64{}
+	for ci, comp := range comps {
+		rest := []float64{1}
+		for cj, other := range comps {
+			if cj != ci {
+				rest = convolve(rest, other.solutions)
+			}
+		}
+		for i, cell := range comp.cells {
+			var p float64
+			for k, count := range comp.cellMines[i] {
+				if count == 0 {
+					continue
+				}
+				for m, r := range rest {
+					p += count * r * scaled(k+m)
+				}
+			}
+			p /= norm
+			result[image.Pt(cell%width, cell/width)] = p
+		}
+	}
+
+	if minesLeft < 0 || len(interior) == 0 {
+		return result, nil
+	}
+	interiorP := interiorMines / norm / float64(len(interior))
+	for _, cell := range interior {
+		result[image.Pt(cell%width, cell/width)] = interiorP
+	}
+	return result, nil
+}
+
+// Safest picks the unknown tile least likely to be a mine, preferring top-left ones on ties
+func Safest(probabilities map[image.Point]float64) (image.Point, float64, bool) {
+	coords := sortedPoints(probabilities)
+	if len(coords) == 0 {
+		return image.Point{}, 0, false
+	}
+	best := coords[0]
+	for _, c := range coords[1:] {
+		if probabilities[c] < probabilities[best] {
+			best = c
+		}
+	}
+	return best, probabilities[best], true
+}
+
+// sortedPoints returns keys of a probability map in reading order
+func sortedPoints(probabilities map[image.Point]float64) []image.Point {
+	coords := make([]image.Point, 0, len(probabilities))
+	for c := range probabilities {
+		coords = append(coords, c)
+	}
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i].Y != coords[j].Y {
+			return coords[i].Y < coords[j].Y
+		}
+		return coords[i].X < coords[j].X
+	})
+	return coords
+}
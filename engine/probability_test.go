@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"testing"
+)
+
+// bruteForce counts every mine layout of the unknown tiles agreeing with the numbers, and with
+// the mine count when it is known. Without the mine count only frontier tiles are laid out,
+// each of their layouts counting once, as MineProbabilities weighs them.
+func bruteForce(b Board, minesLeft int) (map[image.Point]float64, bool) {
+	var cells []image.Point
+	for y := range b {
+		for x, tile := range b[y] {
+			if tile != Unknown {
+				continue
+			}
+			if minesLeft < 0 {
+				// off the frontier without any number around
+				numbered := false
+				tiles, _, _, _ := b.Neighbours(x, y)
+				for _, t := range tiles {
+					numbered = numbered || t >= 1 && t <= 8
+				}
+				if !numbered {
+					continue
+				}
+			}
+			cells = append(cells, image.Pt(x, y))
+		}
+	}
+	mines := make([]int, len(cells))
+	layouts := 0
+	for mask := uint(0); mask < 1<<uint(len(cells)); mask++ {
+		if minesLeft >= 0 && bits.OnesCount(mask) != minesLeft {
+			continue
+		}
+		work := b.Clone()
+		for i, c := range cells {
+			if mask&(1<<uint(i)) != 0 {
+				work[c.Y][c.X] = Flag
+			}
+		}
+		agrees := true
+		for y := range work {
+			for x, tile := range work[y] {
+				if tile >= 1 && tile <= 8 {
+					if _, flagCount := work.Counts(x, y); flagCount != int(tile) {
+						agrees = false
+					}
+				}
+			}
+		}
+		if !agrees {
+			continue
+		}
+		layouts++
+		for i := range cells {
+			if mask&(1<<uint(i)) != 0 {
+				mines[i]++
+			}
+		}
+	}
+	if layouts == 0 {
+		return nil, false
+	}
+	result := map[image.Point]float64{}
+	for i, c := range cells {
+		result[c] = float64(mines[i]) / float64(layouts)
+	}
+	return result, true
+}
+
+func parse(t *testing.T, rows ...string) Board {
+	b, err := ParseBoard(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMineProbabilitiesMatchBruteForce(t *testing.T) {
+	boards := [][]string{
+		{"1?", "??"},
+		{"?1???"},
+		{"???", "???", "???"},
+		{"?????", "F...F", "1...1"},
+		{"1?", "?1"},
+		{"??1..", "??21.", "???1.", "?????"},
+		{"?1?1?", "?????", "1???2", "??F??"},
+		{"1??", "2??", "1??"},
+	}
+	for _, rows := range boards {
+		b := parse(t, rows...)
+		for minesLeft := -1; minesLeft <= 6; minesLeft++ {
+			want, ok := bruteForce(b, minesLeft)
+			got, err := b.MineProbabilities(minesLeft)
+			if !ok {
+				if err == nil {
+					t.Errorf("%v with %d mines: no layout agrees, got %v", rows, minesLeft, got)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%v with %d mines: %v", rows, minesLeft, err)
+				continue
+			}
+			if len(got) != len(want) {
+				t.Errorf("%v with %d mines: probabilities of %d tiles, want %d", rows, minesLeft, len(got), len(want))
+			}
+			for c, p := range want {
+				if q, ok := got[c]; !ok || math.Abs(p-q) > 1e-12 {
+					t.Errorf("%v with %d mines: %v is a mine with %v, want %v", rows, minesLeft, c, q, p)
+				}
+			}
+		}
+	}
+}
+
+func TestUnknownMineCountProvesNothingOffFrontier(t *testing.T) {
+	for _, rows := range [][]string{
+		{"???", "???", "???"},
+		{"?????", "FFFFF", "11111"},
+	} {
+		b := parse(t, rows...)
+		if actions := exactRules(b, -1); len(actions) != 0 {
+			t.Errorf("%v: certain moves %v without a mine count", rows, actions)
+		}
+		if actions := safestGuess(b, -1); len(actions) != 0 {
+			t.Errorf("%v: guess %v without a frontier", rows, actions)
+		}
+	}
+
+	// the frontier is known exactly, the rest is left to the guess
+	b := parse(t, "?1???")
+	if actions := exactRules(b, -1); len(actions) != 0 {
+		t.Errorf("certain moves %v", actions)
+	}
+	actions := safestGuess(b, -1)
+	if len(actions) != 1 || actions[0].At != image.Pt(0, 0) || actions[0].Confidence != 0.5 {
+		t.Errorf("guess %v, want 0 0 safe with 50%%", actions)
+	}
+	// with the count, the interior is exact: one mine on the frontier leaves it safe
+	actions = exactRules(b, 1)
+	if len(actions) != 2 || actions[0].At != image.Pt(3, 0) || actions[1].At != image.Pt(4, 0) {
+		t.Errorf("with one mine left got %v, want 3 0 and 4 0 safe", actions)
+	}
+}
@@ -0,0 +1,62 @@
+package engine
+
+import "time"
+
+// GameState tells whether a game goes on or how it ended
+type GameState int
+
+// Game states
+const (
+	InProgress GameState = iota
+	Won
+	Lost
+)
+
+func (s GameState) String() string {
+	switch s {
+	case Won:
+		return "won"
+	case Lost:
+		return "lost"
+	default:
+		return "in progress"
+	}
+}
+
+// StateDetector is implemented by frontends that can tell the game state on their own,
+// as of the last ReadField. Their ReadField errors are then treated as failures to see
+// the field rather than as explosions.
+type StateDetector interface {
+	GameState() GameState
+}
+
+// GameClock is implemented by frontends that can read the in-game timer
+type GameClock interface {
+	GameSeconds() (int, bool)
+}
+
+// maxReadFailures is the number of unreadable fields in a row after which a game is abandoned
+const maxReadFailures = 3
+
+// gameState asks the frontend for the game state. Frontends without a detector
+// are assumed to fail reading the field only when the game is lost.
+func (e *engine) gameState(readErr error) GameState {
+	if detector, ok := e.reader.(StateDetector); ok {
+		return detector.GameState()
+	}
+	if readErr != nil {
+		return Lost
+	}
+	return InProgress
+}
+
+// finishStats records how long the game took by the wall clock and by the game timer
+func (e *engine) finishStats() {
+	e.stats.Duration = time.Since(e.started)
+	e.stats.GameSeconds = -1
+	if clock, ok := e.reader.(GameClock); ok {
+		if seconds, ok := clock.GameSeconds(); ok {
+			e.stats.GameSeconds = seconds
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package engine
+
+import "image"
+
+// Building blocks for registered strategies
+var (
+	// TileRules looks at numbered tiles one by one: a tile with as many unknown neighbours
+	// as missing flags has them all mined, a tile with all its flags has the rest safe
+	TileRules Strategy = tileRulesStrategy{}
+	// SubsetRules compares overlapping constraints of neighbouring tiles
+	SubsetRules Strategy = StrategyFunc(func(b Board, _ int) []Action {
+		safe, mines := b.SubsetDeduction()
+		return certain("🧩", safe, mines)
+	})
+	// LinearRules runs Gaussian elimination over the frontier
+	LinearRules Strategy = StrategyFunc(func(b Board, minesLeft int) []Action {
+		safe, mines := b.LinearDeduction(minesLeft)
+		return certain("📐", safe, mines)
+	})
+	// SATRules asks a SAT solver about every frontier tile
+	SATRules Strategy = StrategyFunc(func(b Board, minesLeft int) []Action {
+		safe, mines := b.SATDeduction(minesLeft)
+		return certain("🔬", safe, mines)
+	})
+	// ExactRules takes tiles with mine probability of exactly zero or one
+	ExactRules Strategy = StrategyFunc(exactRules)
+	// SafestGuess clicks the tile least likely to be a mine
+	SafestGuess Strategy = StrategyFunc(safestGuess)
+)
+
+func init() {
+	Register("naive", func() Strategy { return TileRules })
+	Register("rules", func() Strategy { return Chain{TileRules, SubsetRules, ExactRules, SafestGuess} })
+	Register("linear", func() Strategy { return Chain{LinearRules, ExactRules, SafestGuess} })
+	// without the exact fallback, to measure what the rules find on their own
+	Register("rules-only", func() Strategy { return Chain{TileRules, SubsetRules} })
+	Register("linear-only", func() Strategy { return LinearRules })
+	Register("sat", func() Strategy { return Chain{SATRules, ExactRules, SafestGuess} })
+	Register("probability", func() Strategy { return Chain{ExactRules, SafestGuess} })
+}
+
+// tileRulesStrategy applies tile rules until they find nothing new,
+// so a single snapshot yields every move they can prove
+type tileRulesStrategy struct{}
+
+// Decide implements Strategy
+func (tileRulesStrategy) Decide(b Board, minesLeft int) []Action {
+	return tileRules(NewFrontier(b))
+}
+
+// DecideFrontier implements FrontierStrategy, only revisiting tiles around changes
+func (tileRulesStrategy) DecideFrontier(_ Board, _ int, frontier *Frontier) []Action {
+	return tileRules(frontier)
+}
+
+// tileRules starts from dirty frontier tiles: a tile rule only finds something new
+// when the neighbourhood of its tile changes, by the board or by its own deductions.
+// It works on a copy of the packed board of the frontier.
+func tileRules(frontier *Frontier) []Action {
+	work := frontier.Packed().Clone()
+	queue := frontier.TakeDirty()
+	queued := map[image.Point]bool{}
+	for _, c := range queue {
+		queued[c] = true
+	}
+	var safe, mines []image.Point
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		delete(queued, c)
+		tileSafe, tileMines := processTile(work, c.X, c.Y)
+		for _, m := range tileMines {
+			work.Set(m.X, m.Y, Flag)
+			mines = append(mines, m)
+		}
+		for _, s := range tileSafe {
+			// known safe, no longer counted as unknown by the neighbours
+			work.Set(s.X, s.Y, OpenSpace)
+			safe = append(safe, s)
+		}
+		for _, changed := range append(tileSafe, tileMines...) {
+			r := work.around(changed.X, changed.Y)
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					n := image.Pt(x, y)
+					if work.number(x, y) != 0 && !queued[n] {
+						queued[n] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+	}
+	return certain("🔢", safe, mines)
+}
+
+// processTile returns unknown neighbours of a numbered tile that it proves safe or mined
+func processTile(b *Bitboard, x, y int) (safe, mines []image.Point) {
+	tile := b.number(x, y)
+	if tile == 0 {
+		return nil, nil
+	}
+	unknownCount, flagCount := b.Counts(x, y)
+	if unknownCount == 0 {
+		return nil, nil
+	}
+	if unknownCount != tile-flagCount && tile != flagCount {
+		return nil, nil
+	}
+	unknowns := make([]image.Point, 0, unknownCount)
+	r := b.around(x, y)
+	for ny := r.Min.Y; ny < r.Max.Y; ny++ {
+		for nx := r.Min.X; nx < r.Max.X; nx++ {
+			if b.IsUnknown(nx, ny) {
+				unknowns = append(unknowns, image.Pt(nx, ny))
+			}
+		}
+	}
+	// Marking flags
+	if unknownCount == tile-flagCount {
+		return nil, unknowns
+	}
+	// Clicking on safe unknowns
+	return unknowns, nil
+}
+
+func exactRules(b Board, minesLeft int) []Action {
+	probabilities, err := b.MineProbabilities(minesLeft)
+	if err != nil {
+		return nil
+	}
+	var safe, mines []image.Point
+	for _, c := range sortedPoints(probabilities) {
+		switch p := probabilities[c]; {
+		case p == 0:
+			safe = append(safe, c)
+		case p > 1-certaintyMargin:
+			mines = append(mines, c)
+		}
+	}
+	return certain("🧮", safe, mines)
+}
+
+func safestGuess(b Board, minesLeft int) []Action {
+	probabilities, err := b.MineProbabilities(minesLeft)
+	if err != nil {
+		return nil
+	}
+	c, p, ok := Safest(probabilities)
+	if !ok {
+		return nil
+	}
+	return []Action{{Kind: RevealAction, At: c, Confidence: 1 - p, Rule: "🎲"}}
+}
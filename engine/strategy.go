@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+// ActionKind tells what to do with a tile
+type ActionKind int
+
+// Possible actions
+const (
+	RevealAction ActionKind = iota
+	FlagAction
+	ChordAction // click on a number revealing all its unflagged neighbours, see Chorder
+)
+
+var actionNames = []string{"reveal", "flag", "chord"}
+
+func (k ActionKind) String() string {
+	if int(k) < len(actionNames) {
+		return actionNames[k]
+	}
+	return fmt.Sprintf("action(%d)", int(k))
+}
+
+// ParseActionKind reads an action kind from its name
+func ParseActionKind(name string) (ActionKind, error) {
+	for k, n := range actionNames {
+		if n == name {
+			return ActionKind(k), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown action: %s", name)
+}
+
+// Action is a move proposed by a strategy.
+// Confidence is the chance that the move is right: 1 for proven moves, less for guesses.
+type Action struct {
+	Kind       ActionKind
+	At         image.Point
+	Confidence float64
+	Rule       string // what came up with the move, for logs
+}
+
+// Strategy decides on moves given a board snapshot and a number of unflagged mines, -1 if unknown.
+// No actions means the strategy has nothing to offer.
+type Strategy interface {
+	Decide(board Board, minesLeft int) []Action
+}
+
+// StrategyFunc adapts a plain function to the Strategy interface
+type StrategyFunc func(board Board, minesLeft int) []Action
+
+// Decide calls the function
+func (f StrategyFunc) Decide(board Board, minesLeft int) []Action {
+	return f(board, minesLeft)
+}
+
+// Chain asks strategies in order and returns the first non-empty answer
+type Chain []Strategy
+
+// Decide implements Strategy
+func (c Chain) Decide(board Board, minesLeft int) []Action {
+	for _, s := range c {
+		if actions := s.Decide(board, minesLeft); len(actions) > 0 {
+			return actions
+		}
+	}
+	return nil
+}
+
+// DecideFrontier implements FrontierStrategy, passing the frontier on to strategies using it
+func (c Chain) DecideFrontier(board Board, minesLeft int, frontier *Frontier) []Action {
+	for _, s := range c {
+		if actions := decide(s, board, minesLeft, frontier); len(actions) > 0 {
+			return actions
+		}
+	}
+	return nil
+}
+
+// decide asks a strategy, from the frontier when it can use one
+func decide(s Strategy, board Board, minesLeft int, frontier *Frontier) []Action {
+	if fs, ok := s.(FrontierStrategy); ok && frontier != nil {
+		return fs.DecideFrontier(board, minesLeft, frontier)
+	}
+	return s.Decide(board, minesLeft)
+}
+
+// DefaultStrategy is used by engines unless told otherwise
+const DefaultStrategy = "rules"
+
+var registry = map[string]func() Strategy{}
+
+// Register makes a strategy available by name
+func Register(name string, factory func() Strategy) {
+	registry[name] = factory
+}
+
+// NewStrategy creates a registered strategy
+func NewStrategy(name string) (Strategy, error) {
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown strategy: %s", name)
+	}
+	return factory(), nil
+}
+
+// Strategies lists names of registered strategies
+func Strategies() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// certain turns proven tiles into actions, flags first
+func certain(rule string, safe, mines []image.Point) []Action {
+	actions := make([]Action, 0, len(safe)+len(mines))
+	for _, c := range mines {
+		actions = append(actions, Action{Kind: FlagAction, At: c, Confidence: 1, Rule: rule})
+	}
+	for _, c := range safe {
+		actions = append(actions, Action{Kind: RevealAction, At: c, Confidence: 1, Rule: rule})
+	}
+	return actions
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"./engine"
+	"./quartz"
+)
+
+// newFrontend creates a frontend playing the macOS Minesweeper
+func newFrontend(options frontendOptions) engine.Frontend {
+	window := quartz.New()
+	if options.Title != "" {
+		window.Title = options.Title
+	}
+	if options.NewGame != nil {
+		window.NewGameChord = *options.NewGame
+	}
+	window.SetClickDuration(options.ClickDuration)
+	window.MaxHashDistance = options.MaxDistance
+	window.Recognizer = options.Recognizer
+	window.MiddleClickChord = options.MiddleChord
+	return window
+}
@@ -0,0 +1,255 @@
+package keys
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key is a platform-neutral keyboard key, backends translate it to their own codes
+type Key int
+
+// Keys
+const (
+	None Key = iota
+
+	// Modifiers
+	Command // ⌘ on macOS, Super elsewhere
+	Control
+	Shift
+	Option // Alt outside macOS
+	RightControl
+	RightShift
+	RightOption
+	Function // fn on Mac keyboards, handled by the keyboard itself elsewhere
+
+	A
+	B
+	C
+	D
+	E
+	F
+	G
+	H
+	I
+	J
+	K
+	L
+	M
+	N
+	O
+	P
+	Q
+	R
+	S
+	T
+	U
+	V
+	W
+	X
+	Y
+	Z
+
+	Num0
+	Num1
+	Num2
+	Num3
+	Num4
+	Num5
+	Num6
+	Num7
+	Num8
+	Num9
+
+	F1
+	F2
+	F3
+	F4
+	F5
+	F6
+	F7
+	F8
+	F9
+	F10
+	F11
+	F12
+	F13
+	F14
+	F15
+	F16
+	F17
+	F18
+	F19
+	F20
+
+	Keypad0
+	Keypad1
+	Keypad2
+	Keypad3
+	Keypad4
+	Keypad5
+	Keypad6
+	Keypad7
+	Keypad8
+	Keypad9
+	KeypadDecimal
+	KeypadPlus
+	KeypadMinus
+	KeypadMultiply
+	KeypadDivide
+	KeypadEquals
+	KeypadEnter
+	KeypadClear
+
+	Escape
+	Return
+	Tab
+	Space
+	Backspace
+	Delete
+	Home
+	End
+	PageUp
+	PageDown
+	Left
+	Right
+	Up
+	Down
+	CapsLock
+
+	Minus
+	Equal
+	LeftBracket
+	RightBracket
+	Backslash
+	Semicolon
+	Quote
+	Comma
+	Period
+	Slash
+	Grave
+)
+
+// names are used in chords, the first name of a key is the one printed
+var names = map[Key][]string{
+	Command: {"cmd", "command", "super"},
+	Control: {"ctrl", "control"},
+	Shift:   {"shift"},
+	Option:  {"alt", "option", "opt"},
+
+	RightControl: {"rctrl", "rcontrol"},
+	RightShift:   {"rshift"},
+	RightOption:  {"ralt", "roption", "ropt"},
+	Function:     {"fn", "function"},
+
+	Escape:    {"esc", "escape"},
+	Return:    {"enter", "return"},
+	Tab:       {"tab"},
+	Space:     {"space"},
+	Backspace: {"backspace"},
+	Delete:    {"delete", "del"},
+	Home:      {"home"},
+	End:       {"end"},
+	PageUp:    {"pageup", "pgup"},
+	PageDown:  {"pagedown", "pgdn"},
+	Left:      {"left"},
+	Right:     {"right"},
+	Up:        {"up"},
+	Down:      {"down"},
+	CapsLock:  {"capslock", "caps"},
+
+	KeypadDecimal:  {"kp."},
+	KeypadPlus:     {"kp+"},
+	KeypadMinus:    {"kp-"},
+	KeypadMultiply: {"kp*"},
+	KeypadDivide:   {"kp/"},
+	KeypadEquals:   {"kp="},
+	KeypadEnter:    {"kpenter"},
+	KeypadClear:    {"kpclear"},
+
+	Minus:        {"-", "minus"},
+	Equal:        {"=", "equal"},
+	LeftBracket:  {"["},
+	RightBracket: {"]"},
+	Backslash:    {"\\"},
+	Semicolon:    {";"},
+	Quote:        {"'"},
+	Comma:        {","},
+	Period:       {"."},
+	Slash:        {"/"},
+	Grave:        {"`"},
+}
+
+func init() {
+	for k := A; k <= Z; k++ {
+		names[k] = []string{string(rune('a' + k - A))}
+	}
+	for k := Num0; k <= Num9; k++ {
+		names[k] = []string{string(rune('0' + k - Num0))}
+	}
+	for k := F1; k <= F20; k++ {
+		names[k] = []string{fmt.Sprintf("f%d", k-F1+1)}
+	}
+	for k := Keypad0; k <= Keypad9; k++ {
+		names[k] = []string{fmt.Sprintf("kp%d", k-Keypad0)}
+	}
+}
+
+// IsModifier reports that a key is held down while another one is pressed
+func (k Key) IsModifier() bool {
+	return k >= Command && k <= Function
+}
+
+func (k Key) String() string {
+	if n, ok := names[k]; ok {
+		return n[0]
+	}
+	return fmt.Sprintf("key(%d)", int(k))
+}
+
+// Parse finds a key by name, ignoring case
+func Parse(name string) (Key, error) {
+	name = strings.ToLower(name)
+	for k, n := range names {
+		for _, alias := range n {
+			if alias == name {
+				return k, nil
+			}
+		}
+	}
+	return None, fmt.Errorf("Unknown key %q", name)
+}
+
+// Chord is a key pressed while holding modifiers
+type Chord struct {
+	Modifiers []Key
+	Key       Key
+}
+
+// ParseChord reads chords like "cmd+n", "ctrl+shift+n" or "f2"
+func ParseChord(s string) (Chord, error) {
+	parts := strings.Split(s, "+")
+	var c Chord
+	for i, part := range parts {
+		k, err := Parse(strings.TrimSpace(part))
+		if err != nil {
+			return Chord{}, err
+		}
+		if i < len(parts)-1 {
+			if !k.IsModifier() {
+				return Chord{}, fmt.Errorf("%s is not a modifier in %q", k, s)
+			}
+			c.Modifiers = append(c.Modifiers, k)
+		} else {
+			c.Key = k
+		}
+	}
+	return c, nil
+}
+
+func (c Chord) String() string {
+	parts := make([]string, 0, len(c.Modifiers)+1)
+	for _, m := range c.Modifiers {
+		parts = append(parts, m.String())
+	}
+	return strings.Join(append(parts, c.Key.String()), "+")
+}
@@ -0,0 +1,43 @@
+package keys
+
+import "testing"
+
+func TestNamesParseBack(t *testing.T) {
+	for k := Command; k <= Grave; k++ {
+		name := k.String()
+		if _, ok := names[k]; !ok {
+			t.Errorf("key %d has no name", int(k))
+			continue
+		}
+		if parsed, err := Parse(name); err != nil || parsed != k {
+			t.Errorf("%s parsed as %s, %v", name, parsed, err)
+		}
+	}
+}
+
+func TestParseChord(t *testing.T) {
+	for s, want := range map[string]Chord{
+		"cmd+n":         {[]Key{Command}, N},
+		"ctrl+shift+N":  {[]Key{Control, Shift}, N},
+		"f2":            {nil, F2},
+		"rshift+f13":    {[]Key{RightShift}, F13},
+		"fn+f20":        {[]Key{Function}, F20},
+		"ralt+kp5":      {[]Key{RightOption}, Keypad5},
+		"rctrl+kpenter": {[]Key{RightControl}, KeypadEnter},
+		"capslock":      {nil, CapsLock},
+	} {
+		c, err := ParseChord(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if c.String() != want.String() {
+			t.Errorf("%s parsed as %s, want %s", s, c, want)
+		}
+	}
+	for _, s := range []string{"n+cmd", "capslock+n", "kp10", "cmd+"} {
+		if c, err := ParseChord(s); err == nil {
+			t.Errorf("%s parsed as %s", s, c)
+		}
+	}
+}
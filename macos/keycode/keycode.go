@@ -52,6 +52,70 @@ const (
 	KeySlash        Code = 0x2C
 	KeyN            Code = 0x2D
 	KeyM            Code = 0x2E
+	KeyPeriod       Code = 0x2F
+	KeyGrave        Code = 0x32
+
+	KeyReturn        Code = 0x24
+	KeyTab           Code = 0x30
+	KeySpace         Code = 0x31
+	KeyDelete        Code = 0x33 // backspace
+	KeyEscape        Code = 0x35
+	KeyShift         Code = 0x38
+	KeyOption        Code = 0x3A
+	KeyControl       Code = 0x3B
+	KeyF1            Code = 0x7A
+	KeyF2            Code = 0x78
+	KeyF3            Code = 0x63
+	KeyF4            Code = 0x76
+	KeyF5            Code = 0x60
+	KeyF6            Code = 0x61
+	KeyF7            Code = 0x62
+	KeyF8            Code = 0x64
+	KeyF9            Code = 0x65
+	KeyF10           Code = 0x6D
+	KeyF11           Code = 0x67
+	KeyF12           Code = 0x6F
+	KeyHome          Code = 0x73
+	KeyPageUp        Code = 0x74
+	KeyForwardDelete Code = 0x75
+	KeyEnd           Code = 0x77
+	KeyPageDown      Code = 0x79
+	KeyLeftArrow     Code = 0x7B
+	KeyRightArrow    Code = 0x7C
+	KeyDownArrow     Code = 0x7D
+	KeyUpArrow       Code = 0x7E
+	KeyCapsLock      Code = 0x39
+	KeyRightShift    Code = 0x3C
+	KeyRightOption   Code = 0x3D
+	KeyRightControl  Code = 0x3E
+	KeyFunction      Code = 0x3F
+	KeyF13           Code = 0x69
+	KeyF14           Code = 0x6B
+	KeyF15           Code = 0x71
+	KeyF16           Code = 0x6A
+	KeyF17           Code = 0x40
+	KeyF18           Code = 0x4F
+	KeyF19           Code = 0x50
+	KeyF20           Code = 0x5A
+
+	KeyKeypadDecimal  Code = 0x41
+	KeyKeypadMultiply Code = 0x43
+	KeyKeypadPlus     Code = 0x45
+	KeyKeypadClear    Code = 0x47
+	KeyKeypadDivide   Code = 0x4B
+	KeyKeypadEnter    Code = 0x4C
+	KeyKeypadMinus    Code = 0x4E
+	KeyKeypadEquals   Code = 0x51
+	KeyKeypad0        Code = 0x52
+	KeyKeypad1        Code = 0x53
+	KeyKeypad2        Code = 0x54
+	KeyKeypad3        Code = 0x55
+	KeyKeypad4        Code = 0x56
+	KeyKeypad5        Code = 0x57
+	KeyKeypad6        Code = 0x58
+	KeyKeypad7        Code = 0x59
+	KeyKeypad8        Code = 0x5B
+	KeyKeypad9        Code = 0x5C
 )
 
 /* From Carbon Events.h
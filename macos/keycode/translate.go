@@ -0,0 +1,96 @@
+package keycode
+
+import (
+	"fmt"
+
+	"../../keys"
+)
+
+// fromKey maps platform-neutral keys to macOS virtual key codes
+var fromKey = map[keys.Key]Code{
+	keys.Command: KeyCommand,
+	keys.Control: KeyControl,
+	keys.Shift:   KeyShift,
+	keys.Option:  KeyOption,
+
+	keys.RightControl: KeyRightControl,
+	keys.RightShift:   KeyRightShift,
+	keys.RightOption:  KeyRightOption,
+	keys.Function:     KeyFunction,
+
+	keys.A: KeyA, keys.B: KeyB, keys.C: KeyC, keys.D: KeyD, keys.E: KeyE, keys.F: KeyF,
+	keys.G: KeyG, keys.H: KeyH, keys.I: KeyI, keys.J: KeyJ, keys.K: KeyK, keys.L: KeyL,
+	keys.M: KeyM, keys.N: KeyN, keys.O: KeyO, keys.P: KeyP, keys.Q: KeyQ, keys.R: KeyR,
+	keys.S: KeyS, keys.T: KeyT, keys.U: KeyU, keys.V: KeyV, keys.W: KeyW, keys.X: KeyX,
+	keys.Y: KeyY, keys.Z: KeyZ,
+
+	keys.Num0: Key0, keys.Num1: Key1, keys.Num2: Key2, keys.Num3: Key3, keys.Num4: Key4,
+	keys.Num5: Key5, keys.Num6: Key6, keys.Num7: Key7, keys.Num8: Key8, keys.Num9: Key9,
+
+	keys.F1: KeyF1, keys.F2: KeyF2, keys.F3: KeyF3, keys.F4: KeyF4, keys.F5: KeyF5, keys.F6: KeyF6,
+	keys.F7: KeyF7, keys.F8: KeyF8, keys.F9: KeyF9, keys.F10: KeyF10, keys.F11: KeyF11, keys.F12: KeyF12,
+	keys.F13: KeyF13, keys.F14: KeyF14, keys.F15: KeyF15, keys.F16: KeyF16,
+	keys.F17: KeyF17, keys.F18: KeyF18, keys.F19: KeyF19, keys.F20: KeyF20,
+
+	keys.Keypad0: KeyKeypad0, keys.Keypad1: KeyKeypad1, keys.Keypad2: KeyKeypad2, keys.Keypad3: KeyKeypad3,
+	keys.Keypad4: KeyKeypad4, keys.Keypad5: KeyKeypad5, keys.Keypad6: KeyKeypad6, keys.Keypad7: KeyKeypad7,
+	keys.Keypad8: KeyKeypad8, keys.Keypad9: KeyKeypad9,
+	keys.KeypadDecimal:  KeyKeypadDecimal,
+	keys.KeypadPlus:     KeyKeypadPlus,
+	keys.KeypadMinus:    KeyKeypadMinus,
+	keys.KeypadMultiply: KeyKeypadMultiply,
+	keys.KeypadDivide:   KeyKeypadDivide,
+	keys.KeypadEquals:   KeyKeypadEquals,
+	keys.KeypadEnter:    KeyKeypadEnter,
+	keys.KeypadClear:    KeyKeypadClear,
+
+	keys.Escape:    KeyEscape,
+	keys.Return:    KeyReturn,
+	keys.Tab:       KeyTab,
+	keys.Space:     KeySpace,
+	keys.Backspace: KeyDelete,
+	keys.Delete:    KeyForwardDelete,
+	keys.Home:      KeyHome,
+	keys.End:       KeyEnd,
+	keys.PageUp:    KeyPageUp,
+	keys.PageDown:  KeyPageDown,
+	keys.Left:      KeyLeftArrow,
+	keys.Right:     KeyRightArrow,
+	keys.Up:        KeyUpArrow,
+	keys.Down:      KeyDownArrow,
+	keys.CapsLock:  KeyCapsLock,
+
+	keys.Minus:        KeyMinus,
+	keys.Equal:        KeyEqual,
+	keys.LeftBracket:  KeyLeftBracket,
+	keys.RightBracket: KeyRightBracket,
+	keys.Backslash:    KeyBackslash,
+	keys.Semicolon:    KeySemicolon,
+	keys.Quote:        KeyQuote,
+	keys.Comma:        KeyComma,
+	keys.Period:       KeyPeriod,
+	keys.Slash:        KeySlash,
+	keys.Grave:        KeyGrave,
+}
+
+// FromKey translates a platform-neutral key
+func FromKey(k keys.Key) (Code, bool) {
+	code, ok := fromKey[k]
+	return code, ok
+}
+
+// FromChord translates a chord into codes of its key and modifiers
+func FromChord(c keys.Chord) (key Code, modifiers []Code, err error) {
+	var ok bool
+	if key, ok = FromKey(c.Key); !ok {
+		return 0, nil, fmt.Errorf("No macOS key code for %s", c.Key)
+	}
+	for _, m := range c.Modifiers {
+		code, ok := FromKey(m)
+		if !ok {
+			return 0, nil, fmt.Errorf("No macOS key code for %s", m)
+		}
+		modifiers = append(modifiers, code)
+	}
+	return key, modifiers, nil
+}
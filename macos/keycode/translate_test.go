@@ -0,0 +1,22 @@
+package keycode
+
+import (
+	"testing"
+
+	"../../keys"
+)
+
+func TestEveryKeyHasCode(t *testing.T) {
+	seen := map[Code]keys.Key{}
+	for k := keys.Command; k <= keys.Grave; k++ {
+		code, ok := FromKey(k)
+		if !ok {
+			t.Errorf("no key code for %s", k)
+			continue
+		}
+		if other, ok := seen[code]; ok {
+			t.Errorf("%s and %s share key code %#x", k, other, code)
+		}
+		seen[code] = k
+	}
+}
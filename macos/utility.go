@@ -1,3 +1,6 @@
+//go:build darwin
+// +build darwin
+
 package macos
 
 /*
@@ -50,9 +53,10 @@ func FindWindow(targetAppTitle string) (WindowMeta, error) {
 	return info, errors.New("Window not found")
 }
 
-// TakeScreenshot takes screenshot of a window with provided ID
+// TakeScreenshot takes screenshot of a window with provided ID at full display resolution,
+// which is larger than window bounds in points on Retina displays
 func TakeScreenshot(windowID int) *image.RGBA { // TODO handle errors
-	const flags = C.kCGWindowImageDefault | C.kCGWindowImageShouldBeOpaque | C.kCGWindowImageNominalResolution | C.kCGWindowImageBoundsIgnoreFraming
+	const flags = C.kCGWindowImageDefault | C.kCGWindowImageShouldBeOpaque | C.kCGWindowImageBoundsIgnoreFraming
 	windowList := WrapIntAsCFArrayRef(windowID)
 	screenShot := C.CGWindowListCreateImageFromArray(C.CGRectNull, windowList, flags)
 
@@ -99,6 +103,30 @@ func RightClickT(x, y int, delay time.Duration) {
 	genericClick(C.kCGEventRightMouseDown, C.kCGEventRightMouseUp, C.kCGMouseButtonRight, x, y, MouseClickDuration)
 }
 
+// MiddleClickT clicks the middle button with a custom delay
+func MiddleClickT(x, y int, delay time.Duration) {
+	genericClick(C.kCGEventOtherMouseDown, C.kCGEventOtherMouseUp, C.kCGMouseButtonCenter, x, y, delay)
+}
+
+// BothClickT presses left and right buttons together, the classic Minesweeper chord
+func BothClickT(x, y int, delay time.Duration) {
+	point := C.CGPointMake(C.CGFloat(x), C.CGFloat(y))
+	leftDown := CoreGraphics.CreateMouseEvent(C.kCGEventLeftMouseDown, point, C.kCGMouseButtonLeft)
+	rightDown := CoreGraphics.CreateMouseEvent(C.kCGEventRightMouseDown, point, C.kCGMouseButtonRight)
+	leftUp := CoreGraphics.CreateMouseEvent(C.kCGEventLeftMouseUp, point, C.kCGMouseButtonLeft)
+	rightUp := CoreGraphics.CreateMouseEvent(C.kCGEventRightMouseUp, point, C.kCGMouseButtonRight)
+	defer releaseEvent(leftDown)
+	defer releaseEvent(rightDown)
+	defer releaseEvent(leftUp)
+	defer releaseEvent(rightUp)
+	C.CGEventPost(C.kCGHIDEventTap, leftDown)
+	C.CGEventPost(C.kCGHIDEventTap, rightDown)
+	time.Sleep(delay)
+	C.CGEventPost(C.kCGHIDEventTap, leftUp)
+	C.CGEventPost(C.kCGHIDEventTap, rightUp)
+	time.Sleep(delay)
+}
+
 func genericClick(downEventType, upEventType C.CGEventType, button C.CGMouseButton, x, y int, duration time.Duration) {
 	point := C.CGPointMake(C.CGFloat(x), C.CGFloat(y))
 	downEvent := CoreGraphics.CreateMouseEvent(downEventType, point, button)
@@ -125,15 +153,24 @@ func KeyPress(keyCode keycode.Code) {
 
 // KeyPressWithModifier emulates keyboard press with modifier key
 func KeyPressWithModifier(keyCode, modifierKeyCode keycode.Code) {
-	modifierDownEvent := CoreGraphics.CreateKeyboardEvent(modifierKeyCode, true)
-	modifierUpEvent := CoreGraphics.CreateKeyboardEvent(modifierKeyCode, false)
-	defer releaseEvent(modifierDownEvent)
-	defer releaseEvent(modifierUpEvent)
-	C.CGEventPost(C.kCGHIDEventTap, modifierDownEvent)
-	time.Sleep(keyPressDuration)
+	KeyChord(keyCode, modifierKeyCode)
+}
+
+// KeyChord emulates keyboard press holding any number of modifier keys
+func KeyChord(keyCode keycode.Code, modifierKeyCodes ...keycode.Code) {
+	for _, modifier := range modifierKeyCodes {
+		modifierDownEvent := CoreGraphics.CreateKeyboardEvent(modifier, true)
+		C.CGEventPost(C.kCGHIDEventTap, modifierDownEvent)
+		releaseEvent(modifierDownEvent)
+		time.Sleep(keyPressDuration)
+	}
 	KeyPress(keyCode)
-	C.CGEventPost(C.kCGHIDEventTap, modifierUpEvent)
-	time.Sleep(keyPressDuration)
+	for i := len(modifierKeyCodes) - 1; i >= 0; i-- {
+		modifierUpEvent := CoreGraphics.CreateKeyboardEvent(modifierKeyCodes[i], false)
+		C.CGEventPost(C.kCGHIDEventTap, modifierUpEvent)
+		releaseEvent(modifierUpEvent)
+		time.Sleep(keyPressDuration)
+	}
 }
 
 // ActivateWindow brings window of a selected app to front
@@ -1,26 +1,222 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"image"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	"./engine"
+	"./keys"
+	"./record"
+	"./sim"
+	"./vision"
 )
 
+// commands are selected by the first argument, playing is the default
+var commands = map[string]func(args []string){
+	"play":      play,
+	"bench":     bench,
+	"replay":    replay,
+	"recognize": recognize,
+	"calibrate": calibrate,
+}
+
 func main() {
-	bot := engine.NewEngine()
-	bot.SetClickDuration(15 * time.Millisecond)
-	err := bot.Start()
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+	play(os.Args[1:])
+}
+
+// strategyFlag registers the common -strategy flag
+func strategyFlag(flags *flag.FlagSet) *string {
+	return flags.String("strategy", engine.DefaultStrategy,
+		"solver strategy, one of: "+strings.Join(engine.Strategies(), ", "))
+}
+
+// chordFlag registers the common -chord flag
+func chordFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("chord", false, "open neighbours of numbers with all their flags by chording")
+}
+
+// newStrategy creates a registered strategy, making it chord when asked to
+func newStrategy(name string, chord bool) engine.Strategy {
+	strategy, err := engine.NewStrategy(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if chord {
+		strategy = engine.Chords{Strategy: strategy}
+	}
+	return strategy
+}
+
+// recordFlag registers the common -record flag
+func recordFlag(flags *flag.FlagSet) *string {
+	return flags.String("record", "", "write a recording of every game to this file")
+}
+
+// recognizerFlags registers the common -recognizer flags and returns a constructor of the chosen one
+func recognizerFlags(flags *flag.FlagSet) func(maxDistance int) vision.Recognizer {
+	name := flags.String("recognizer", "hash", "tile recognizer: hash, or template for colour templates")
+	templates := flags.String("templates", "", "directory of tile templates, see recognize -save-templates")
+	templateDistance := flags.Int("template-distance", vision.DefaultMaxTemplateDistance,
+		"largest RMS colour difference of a matching template")
+	return func(maxDistance int) vision.Recognizer {
+		switch *name {
+		case "hash":
+			return vision.HashRecognizer{MaxDistance: maxDistance}
+		case "template":
+			if *templates == "" {
+				log.Fatalln("The template recognizer needs -templates, make them with recognize -save-templates")
+			}
+			recognizer, err := vision.LoadTemplates(*templates)
+			if err != nil {
+				log.Fatal(err)
+			}
+			recognizer.MaxDistance = *templateDistance
+			return recognizer
+		}
+		log.Fatalf("Unknown recognizer %q", *name)
+		return nil
+	}
+}
+
+// openingFlags registers the common -opening flags and returns a constructor of the chosen policy,
+// along with the first click rule of the game
+func openingFlags(flags *flag.FlagSet) func() (engine.OpeningPolicy, engine.FirstClickRule) {
+	name := flags.String("opening", "corner",
+		"first click policy, one of: "+strings.Join(engine.Openings(), ", ")+", or learned from simulated games")
+	firstClick := flags.String("first-click", "safe",
+		"first click guarantee of the game: safe tile, a zero opening, or any for none")
+	learnGames := flags.Int("opening-games", 1000, "simulated layouts per tile for the learned opening")
+	tablePath := flags.String("opening-table", defaultOpeningTable, "file keeping learned openings between runs, empty to learn them every run")
+	return func() (engine.OpeningPolicy, engine.FirstClickRule) {
+		rule, err := engine.ParseFirstClickRule(*firstClick)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *name == "learned" {
+			learned := &engine.LearnedOpening{Rule: rule, Learn: func(width, height, mines int) image.Point {
+				level := sim.Level{Width: width, Height: height, Mines: mines}
+				at, rate := level.LearnOpening(rule, *learnGames, 1)
+				log.Printf("🚪 Learned opening at %d %d, zero chance %.1f%%\n", at.X, at.Y, rate*100)
+				return at
+			}}
+			if *tablePath != "" {
+				loadOpeningTable(learned, *tablePath)
+			}
+			return learned, rule
+		}
+		policy, err := engine.ParseOpening(*name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return policy, rule
+	}
+}
+
+// defaultOpeningTable keeps learned openings when no other file is given
+const defaultOpeningTable = "openings.json"
+
+// loadOpeningTable starts a learned opening from a table file when there is one,
+// and saves the table there whenever a new size is learned
+func loadOpeningTable(learned *engine.LearnedOpening, path string) {
+	table, err := engine.LoadOpeningTable(path)
+	switch {
+	case err == nil:
+		log.Printf("🚪 Using %d learned openings from %s\n", len(table), path)
+		learned.Table = table
+	case !os.IsNotExist(err):
+		log.Fatalf("%s: %v", path, err)
+	}
+	learned.Learned = func(table engine.OpeningTable) {
+		if err := table.Save(path); err != nil {
+			log.Println("🚪 Cannot save learned openings:", err)
+		}
+	}
+}
+
+func startRecording(path string) (*record.Recorder, *os.File) {
+	file, err := os.Create(path)
 	if err != nil {
 		log.Fatal(err)
 	}
+	return record.NewRecorder(file), file
+}
+
+// frontendOptions configure the game window frontend of the platform, zero values keep its defaults
+type frontendOptions struct {
+	Title         string
+	NewGame       *keys.Chord
+	ClickDuration time.Duration
+	MaxDistance   int
+	Recognizer    vision.Recognizer
+	MiddleChord   bool
+}
+
+func play(args []string) {
+	flags := flag.NewFlagSet("play", flag.ExitOnError)
+	strategyName := strategyFlag(flags)
+	chord := chordFlag(flags)
+	middleClickChord := flags.Bool("middle-click-chord", false, "chord with the middle button instead of both buttons")
+	validate := flags.Bool("validate", false, "check every certain move with the SAT oracle")
+	recordPath := recordFlag(flags)
+	maxDistance := flags.Int("max-distance", vision.DefaultMaxDistance, "largest Hamming distance of a matching tile hash")
+	calibrationPath := calibrationFlag(flags)
+	newRecognizer := recognizerFlags(flags)
+	title := flags.String("title", "", "title of the game window, the platform game by default")
+	clickDuration := flags.Duration("click-duration", 15*time.Millisecond, "delay between mouse down and up events")
+	newGame := flags.String("new-game", "", "shortcut starting a new game like cmd+n or f2, the platform game one by default")
+	newOpening := openingFlags(flags)
+	flags.Parse(args)
+	loadCalibration(*calibrationPath)
+	strategy := newStrategy(*strategyName, *chord)
+
+	options := frontendOptions{
+		Title:         *title,
+		ClickDuration: *clickDuration,
+		MaxDistance:   *maxDistance,
+		Recognizer:    newRecognizer(*maxDistance),
+		MiddleChord:   *middleClickChord,
+	}
+	if *newGame != "" {
+		chord, err := keys.ParseChord(*newGame)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options.NewGame = &chord
+	}
+	bot := engine.NewEngine(newFrontend(options))
+	bot.SetStrategy(strategy)
+	bot.SetValidation(*validate)
+	bot.SetOpening(newOpening())
+	if *recordPath != "" {
+		recorder, file := startRecording(*recordPath)
+		defer file.Close()
+		recorder.Seed = bot.GameSeed
+		bot.SetObserver(recorder)
+	}
+	if err := bot.Start(); err != nil {
+		log.Fatal(err)
+	}
 
 	maxRetries := 5
 	var success bool
+	var games []engine.Stats
+	defer func() { reportTimes(games, *clickDuration) }()
 	for !success {
 		bot.StartGame()
 
 		success = bot.GameLoop()
+		games = append(games, bot.Stats())
 		if success {
 			break
 		}
@@ -33,3 +229,19 @@ func main() {
 		}
 	}
 }
+
+// reportTimes logs wall-clock and in-game times of played games, to compare click durations
+func reportTimes(games []engine.Stats, clickDuration time.Duration) {
+	var total time.Duration
+	for i, stats := range games {
+		total += stats.Duration
+		timer := "timer not read"
+		if stats.GameSeconds >= 0 {
+			timer = fmt.Sprintf("timer %ds", stats.GameSeconds)
+		}
+		log.Printf("⏱  Game %d: %v, %s, %d moves, %d captures", i+1, stats.Duration.Round(time.Millisecond), timer, stats.Moves, stats.Captures)
+	}
+	if len(games) > 0 {
+		log.Printf("⏱  %v per game with %v clicks", (total / time.Duration(len(games))).Round(time.Millisecond), clickDuration)
+	}
+}
@@ -0,0 +1,156 @@
+//go:build darwin
+// +build darwin
+
+package quartz
+
+import (
+	"image"
+	"log"
+	"time"
+
+	"../engine"
+	"../keys"
+	"../macos"
+	"../macos/keycode"
+	"../vision"
+)
+
+// DefaultTitle is the owner name of the macOS Minesweeper window
+const DefaultTitle = "Minesweeper"
+
+// DefaultNewGameChord starts a new game, ⌘N
+var DefaultNewGameChord = keys.Chord{Modifiers: []keys.Key{keys.Command}, Key: keys.N}
+
+// Window plays the macOS Minesweeper window
+type Window struct {
+	x, y          int
+	width, height uint
+	windowID      int
+	scale         vision.Scale
+	vision        *vision.Vision
+	// new game chord translated at start
+	newGameKey       keycode.Code
+	newGameModifiers []keycode.Code
+
+	Title         string
+	ClickDuration time.Duration
+	// NewGameChord is the shortcut of the game for starting over
+	NewGameChord keys.Chord
+	// MaxHashDistance is passed to recognition, see vision.Vision
+	MaxHashDistance int
+	// Recognizer tells tiles apart, average hashes when nil
+	Recognizer vision.Recognizer
+	// MiddleClickChord chords with the middle button instead of both buttons at once
+	MiddleClickChord bool
+}
+
+// New creates a frontend for the macOS Minesweeper
+func New() *Window {
+	return &Window{
+		Title:           DefaultTitle,
+		NewGameChord:    DefaultNewGameChord,
+		ClickDuration:   macos.MouseClickDuration,
+		MaxHashDistance: vision.DefaultMaxDistance,
+	}
+}
+
+// Start finds the game window and brings it to front
+func (w *Window) Start() (uint, uint, error) {
+	var err error
+	w.newGameKey, w.newGameModifiers, err = keycode.FromChord(w.NewGameChord)
+	if err != nil {
+		return 0, 0, err
+	}
+	winMeta, err := macos.FindWindow(w.Title)
+	log.Printf("%+v\n", winMeta)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	w.windowID = winMeta.ID
+	w.x = winMeta.Bounds.X()
+	w.y = winMeta.Bounds.Y()
+	screenshot := macos.TakeScreenshot(w.windowID)
+	w.scale = vision.ScaleOf(screenshot.Bounds().Dx(), winMeta.Bounds.Width())
+	grid, err := vision.WindowGrid(screenshot, w.scale)
+	if err != nil {
+		return 0, 0, err
+	}
+	log.Printf("📐 Field grid: %v, %.2f pixels per point", grid, w.scale)
+	w.width, w.height = uint(grid.Columns), uint(grid.Rows)
+	w.vision = vision.NewWithGrid(grid)
+	w.vision.MaxDistance = w.MaxHashDistance
+	w.vision.Recognizer = w.Recognizer
+
+	macos.ActivateWindow(winMeta.OwnerPID)
+	return w.width, w.height, nil
+}
+
+// SetClickDuration sets delay between mouse 'down' and 'up' events
+func (w *Window) SetClickDuration(duration time.Duration) {
+	w.ClickDuration = duration
+}
+
+// GrabScreen returns game area of the window
+func (w Window) GrabScreen() image.Image {
+	img := macos.TakeScreenshot(w.windowID)
+	return img.SubImage(w.vision.Bounds())
+}
+
+// ReadField recognizes the field on a fresh screenshot
+func (w *Window) ReadField(field engine.Board, unknownsOnly bool) error {
+	return w.vision.ReadField(w.GrabScreen().(*image.RGBA), field, unknownsOnly)
+}
+
+// Cleared reports that the bomb counter shows zero
+func (w Window) Cleared() bool {
+	return w.vision.Cleared()
+}
+
+// GameState tells whether the game on the last read field goes on
+func (w Window) GameState() engine.GameState {
+	return w.vision.GameState()
+}
+
+// MinesLeft returns the number on the bomb counter
+func (w Window) MinesLeft() (int, bool) {
+	return w.vision.MinesLeft()
+}
+
+// GameSeconds returns the number on the timer
+func (w Window) GameSeconds() (int, bool) {
+	return w.vision.GameSeconds()
+}
+
+// NewGame presses the new game chord
+func (w Window) NewGame() {
+	macos.KeyChord(w.newGameKey, w.newGameModifiers...)
+}
+
+// tileCenter returns screen coordinates of a tile, the grid is in screenshot pixels
+func (w Window) tileCenter(x, y int) (int, int) {
+	center := w.scale.ToPoints(w.vision.Grid().Center(x, y))
+	return w.x + center.X, w.y + center.Y
+}
+
+// Reveal left-clicks a tile
+func (w Window) Reveal(x, y int) {
+	cx, cy := w.tileCenter(x, y)
+	macos.LeftClickT(cx, cy, w.ClickDuration)
+}
+
+// Flag right-clicks a tile
+func (w Window) Flag(x, y int) {
+	cx, cy := w.tileCenter(x, y)
+	macos.RightClickT(cx, cy, w.ClickDuration)
+}
+
+// Chord clicks a number to reveal its unflagged neighbours
+func (w Window) Chord(x, y int) {
+	cx, cy := w.tileCenter(x, y)
+	if w.MiddleClickChord {
+		macos.MiddleClickT(cx, cy, w.ClickDuration)
+	} else {
+		macos.BothClickT(cx, cy, w.ClickDuration)
+	}
+}
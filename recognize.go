@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"./engine"
+	"./vision"
+)
+
+// recognize decodes saved window screenshots offline, or checks them against expected fields
+func recognize(args []string) {
+	flags := flag.NewFlagSet("recognize", flag.ExitOnError)
+	text := flags.Bool("text", false, "print fields in text form, as used for expected corpus files")
+	check := flags.String("check", "", "directory of screenshot.png files with expected screenshot.txt fields")
+	maxDistance := flags.Int("max-distance", vision.DefaultMaxDistance, "largest Hamming distance of a matching hash")
+	calibrationPath := calibrationFlag(flags)
+	newRecognizer := recognizerFlags(flags)
+	saveTemplates := flags.String("save-templates", "",
+		"save a template of every tile value found on screenshot.png files with expected screenshot.txt fields to this directory")
+	flags.Parse(args)
+	loadCalibration(*calibrationPath)
+
+	if *saveTemplates != "" {
+		extractTemplates(*saveTemplates, flags.Args())
+		return
+	}
+	recognizer := newRecognizer(*maxDistance)
+	if *check != "" {
+		if !checkCorpus(*check, *maxDistance, recognizer) {
+			os.Exit(1)
+		}
+		return
+	}
+	for _, path := range flags.Args() {
+		img, err := vision.LoadPNG(path)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		field, confidence, err := vision.Decode(img, *maxDistance, recognizer)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		footer, err := vision.DecodeFooter(img, *maxDistance)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		if *text {
+			fmt.Println(strings.Join(field.Rows(), "\n"))
+		} else {
+			fmt.Print(field)
+		}
+		// the same lines as in corpus files, check them against the screenshot before keeping them
+		if footer.MinesRead {
+			fmt.Println("mines", footer.MinesLeft)
+		}
+		if footer.TimerRead {
+			fmt.Println("timer", footer.Seconds)
+		}
+		for y := range confidence {
+			for x, c := range confidence[y] {
+				if c < 1 {
+					log.Printf("%s: tile %d %d is %s with confidence %.2f", path, x, y, field[y][x], c)
+				}
+			}
+		}
+	}
+}
+
+// extractTemplates saves the first tile of every value found on screenshots, as labelled by their expected fields
+func extractTemplates(dir string, paths []string) {
+	found := map[engine.Tile]bool{}
+	var templates []vision.Template
+	for _, path := range paths {
+		img, err := vision.LoadPNG(path)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		expected, err := vision.ReadExpected(path)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		extracted, err := vision.ExtractTemplates(img, expected.Field)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		for _, t := range extracted {
+			if !found[t.Tile] {
+				found[t.Tile] = true
+				templates = append(templates, t)
+			}
+		}
+	}
+	if err := vision.SaveTemplates(dir, templates); err != nil {
+		log.Fatal(err)
+	}
+	var names []string
+	for _, t := range templates {
+		names = append(names, t.Tile.String())
+	}
+	log.Printf("💾 Saved %d templates to %s: %s", len(templates), dir, strings.Join(names, " "))
+	if missing := vision.MissingTemplates(templates); len(missing) > 0 {
+		names = nil
+		for _, tile := range missing {
+			names = append(names, tile.String())
+		}
+		log.Println("No screenshot shows", strings.Join(names, " "), "- these tiles will not be recognized")
+	}
+}
+
+// checkCorpus decodes every screenshot of a directory and compares it with the expected field
+func checkCorpus(dir string, maxDistance int, recognizer vision.Recognizer) bool {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(paths) == 0 {
+		log.Println("No screenshots in", dir)
+	}
+	if templates, ok := recognizer.(*vision.TemplateRecognizer); ok {
+		fmt.Printf("templates are %.1f levels apart at least, matching within %d\n", templates.Separation(), templates.MaxDistance)
+	}
+	ok := true
+	for _, path := range paths {
+		if err := vision.CheckScreenshot(path, maxDistance, recognizer); err != nil {
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			ok = false
+		} else {
+			fmt.Println("ok  ", path)
+		}
+	}
+	return ok
+}
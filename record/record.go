@@ -0,0 +1,161 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+
+	"../engine"
+)
+
+// Event is a single line of a recording
+type Event struct {
+	Type       string   `json:"type"` // start, field, action or end
+	Width      int      `json:"width,omitempty"`
+	Height     int      `json:"height,omitempty"`
+	Field      []string `json:"field,omitempty"`
+	Kind       string   `json:"kind,omitempty"` // reveal, flag or chord
+	At         *[2]int  `json:"at,omitempty"`
+	Confidence float64  `json:"confidence,omitempty"`
+	Rule       string   `json:"rule,omitempty"`
+	Won        bool     `json:"won,omitempty"`
+	Mines      [][2]int `json:"mines,omitempty"`
+	Seed       *int64   `json:"seed,omitempty"`
+}
+
+// Event types
+const (
+	startEvent  = "start"
+	fieldEvent  = "field"
+	actionEvent = "action"
+	endEvent    = "end"
+)
+
+// Recorder writes everything the engine does as JSON lines
+type Recorder struct {
+	enc *json.Encoder
+	err error
+	// Layout, when set, is asked for mine positions at the end of every game
+	Layout func() []image.Point
+	// Seed, when set, is asked for the seed of random clicks at the start of every game
+	Seed func() int64
+}
+
+// NewRecorder creates a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Err returns the first write error
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+func (r *Recorder) write(event Event) {
+	if r.err == nil {
+		r.err = r.enc.Encode(event)
+	}
+}
+
+// GameStarted implements engine.Observer
+func (r *Recorder) GameStarted(width, height int) {
+	event := Event{Type: startEvent, Width: width, Height: height}
+	if r.Seed != nil {
+		seed := r.Seed()
+		event.Seed = &seed
+	}
+	r.write(event)
+}
+
+// FieldRead implements engine.Observer
+func (r *Recorder) FieldRead(field engine.Board) {
+	r.write(Event{Type: fieldEvent, Field: field.Rows()})
+}
+
+// ActionPerformed implements engine.Observer
+func (r *Recorder) ActionPerformed(action engine.Action) {
+	r.write(Event{
+		Type:       actionEvent,
+		Kind:       action.Kind.String(),
+		At:         &[2]int{action.At.X, action.At.Y},
+		Confidence: action.Confidence,
+		Rule:       action.Rule,
+	})
+}
+
+// GameEnded implements engine.Observer
+func (r *Recorder) GameEnded(won bool) {
+	event := Event{Type: endEvent, Won: won}
+	if r.Layout != nil {
+		for _, c := range r.Layout() {
+			event.Mines = append(event.Mines, [2]int{c.X, c.Y})
+		}
+	}
+	r.write(event)
+}
+
+// Step is a recorded field snapshot or a move
+type Step struct {
+	Field  engine.Board // nil for moves
+	Action engine.Action
+}
+
+// Game is a recording of one game
+type Game struct {
+	Width, Height int
+	Steps         []Step
+	Finished      bool
+	Won           bool
+	Mines         []image.Point // nil when layout is unknown
+	Seed          *int64        // nil when the seed of random clicks is unknown
+}
+
+// Read parses all games of a recording
+func Read(r io.Reader) ([]Game, error) {
+	var games []Game
+	var game *Game
+	// a decoder has no limit on the line length, fields of big boards make long lines
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var event Event
+		if err := dec.Decode(&event); err == io.EOF {
+			return games, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if event.Type == startEvent {
+			games = append(games, Game{Width: event.Width, Height: event.Height, Seed: event.Seed})
+			game = &games[len(games)-1]
+			continue
+		}
+		if game == nil {
+			return nil, fmt.Errorf("line %d: %s event before game start", line, event.Type)
+		}
+		switch event.Type {
+		case fieldEvent:
+			field, err := engine.ParseBoard(event.Field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			game.Steps = append(game.Steps, Step{Field: field})
+		case actionEvent:
+			kind, err := engine.ParseActionKind(event.Kind)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			action := engine.Action{Kind: kind, Confidence: event.Confidence, Rule: event.Rule}
+			if event.At != nil {
+				action.At = image.Pt(event.At[0], event.At[1])
+			}
+			game.Steps = append(game.Steps, Step{Action: action})
+		case endEvent:
+			game.Finished, game.Won = true, event.Won
+			for _, c := range event.Mines {
+				game.Mines = append(game.Mines, image.Pt(c[0], c[1]))
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown event %q", line, event.Type)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package record
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"../engine"
+	"../sim"
+)
+
+// play records games of a strategy that clicks at random when its rules find nothing
+func play(t *testing.T, board *sim.Board, seed int64, games int) []Game {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	strategy, err := engine.NewStrategy("rules-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot := engine.NewEngine(board)
+	bot.SetStrategy(strategy)
+	bot.SetSeed(seed)
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	recorder.Layout = board.Layout
+	recorder.Seed = bot.GameSeed
+	bot.SetObserver(recorder)
+	if _, err := sim.Play(bot, board, games); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Err() != nil {
+		t.Fatal(recorder.Err())
+	}
+	recorded, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recorded
+}
+
+func actions(game Game) []engine.Action {
+	var result []engine.Action
+	for _, step := range game.Steps {
+		if step.Field == nil {
+			result = append(result, step.Action)
+		}
+	}
+	return result
+}
+
+func TestRecordedSeedReplaysGame(t *testing.T) {
+	games := play(t, sim.Expert.NewBoard(3), 3, 5)
+	if len(games) != 5 {
+		t.Fatalf("read %d games, want 5", len(games))
+	}
+	for i, game := range games {
+		if game.Seed == nil || game.Mines == nil {
+			t.Fatalf("game %d: seed or layout not recorded", i)
+		}
+		again := play(t, sim.NewWithLayout(game.Width, game.Height, game.Mines), *game.Seed, 1)
+		if !reflect.DeepEqual(actions(again[0]), actions(game)) || again[0].Won != game.Won {
+			t.Errorf("game %d plays differently with seed %d", i, *game.Seed)
+		}
+	}
+}
+
+func TestReadLongLines(t *testing.T) {
+	const width, height = 2000, 1000
+	var buf bytes.Buffer
+	r := NewRecorder(&buf)
+	r.GameStarted(width, height)
+	r.FieldRead(engine.NewBoard(width, height))
+	r.GameEnded(false)
+	games, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 || len(games[0].Steps) != 1 || games[0].Steps[0].Field.Width() != width {
+		t.Errorf("read %v", games)
+	}
+}
+
+func TestReadReportsLine(t *testing.T) {
+	recording := `{"type":"start","width":2,"height":1}
+{"type":"field","field":["??"]}
+{"type":"action","kind":"jump"}
+`
+	_, err := Read(strings.NewReader(recording))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("error %v, want one on line 3", err)
+	}
+}
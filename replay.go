@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"./engine"
+	"./record"
+	"./sim"
+)
+
+// replay shows a recorded game step by step, or lets another strategy play the recorded board
+func replay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	index := flags.Int("game", 0, "index of the game in the recording")
+	delay := flags.Duration("delay", 0, "pause between steps, the screen is redrawn in place when set")
+	strategyName := flags.String("strategy", "", "re-run the recorded board with this strategy, needs the mine layout")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatalln("Usage: replay [flags] recording.jsonl")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	games, err := record.Read(file)
+	file.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *index < 0 || *index >= len(games) {
+		log.Fatalf("Recording has %d games", len(games))
+	}
+	game := games[*index]
+
+	if *strategyName != "" {
+		game, err = rerun(game, *strategyName)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	show(game, *delay)
+}
+
+// rerun plays the recorded mine layout again with a different strategy
+func rerun(game record.Game, strategyName string) (record.Game, error) {
+	if game.Mines == nil {
+		return game, fmt.Errorf("Mine layout was not recorded")
+	}
+	strategy, err := engine.NewStrategy(strategyName)
+	if err != nil {
+		return game, err
+	}
+	board := sim.NewWithLayout(game.Width, game.Height, game.Mines)
+	bot := engine.NewEngine(board)
+	bot.SetStrategy(strategy)
+	if game.Seed != nil {
+		bot.SetSeed(*game.Seed)
+	} else {
+		log.Println("🎲 Seed of random clicks was not recorded, they will differ")
+	}
+	var buf bytes.Buffer
+	recorder := record.NewRecorder(&buf)
+	recorder.Layout = board.Layout
+	recorder.Seed = bot.GameSeed
+	bot.SetObserver(recorder)
+
+	log.SetOutput(ioutil.Discard)
+	err = bot.Start()
+	if err == nil {
+		bot.StartGame()
+		bot.GameLoop()
+	}
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		return game, err
+	}
+	games, err := record.Read(&buf)
+	if err != nil {
+		return game, err
+	}
+	return games[0], nil
+}
+
+func show(game record.Game, delay time.Duration) {
+	const clearScreen = "\033[H\033[2J"
+	for i, step := range game.Steps {
+		if step.Field != nil {
+			if delay > 0 {
+				fmt.Print(clearScreen)
+			}
+			fmt.Printf("step %d of %d\n%s", i+1, len(game.Steps), step.Field)
+			continue
+		}
+		a := step.Action
+		verb := "Clicking on"
+		switch a.Kind {
+		case engine.FlagAction:
+			verb = "Setting flag at"
+		case engine.ChordAction:
+			verb = "Chording on"
+		}
+		if a.Confidence > 0 && a.Confidence < 1 {
+			fmt.Printf("%s %s %d %d, mine chance %.1f%%\n", a.Rule, verb, a.At.X, a.At.Y, (1-a.Confidence)*100)
+		} else {
+			fmt.Println(a.Rule, verb, a.At.X, a.At.Y)
+		}
+		time.Sleep(delay)
+	}
+	switch {
+	case !game.Finished:
+		fmt.Println("Recording ends before the game does")
+	case game.Won:
+		fmt.Println("🎉 Victory!")
+	default:
+		fmt.Println("💣 Boom!")
+	}
+}
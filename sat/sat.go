@@ -0,0 +1,282 @@
+package sat
+
+// Literal is a variable number, negated for a negative literal. Variables start at 1.
+type Literal int
+
+// Var returns variable of a literal
+func (l Literal) Var() int {
+	if l < 0 {
+		return int(-l)
+	}
+	return int(l)
+}
+
+// code maps literals to dense indices: 2v for positive, 2v+1 for negative
+func (l Literal) code() int {
+	if l < 0 {
+		return 2*int(-l) + 1
+	}
+	return 2 * int(l)
+}
+
+// Formula is a boolean formula in conjunctive normal form
+type Formula struct {
+	vars    int
+	clauses [][]Literal
+	empty   bool // an empty clause was added, formula cannot be satisfied
+}
+
+// NewVar allocates a fresh variable
+func (f *Formula) NewVar() Literal {
+	f.vars++
+	return Literal(f.vars)
+}
+
+// Vars returns number of variables allocated so far
+func (f *Formula) Vars() int {
+	return f.vars
+}
+
+// Add appends a clause: at least one of the literals is true
+func (f *Formula) Add(clause ...Literal) {
+	seen := map[Literal]bool{}
+	var c []Literal
+	for _, l := range clause {
+		if seen[-l] {
+			return // tautology
+		}
+		if !seen[l] {
+			seen[l] = true
+			c = append(c, l)
+		}
+	}
+	if len(c) == 0 {
+		f.empty = true
+		return
+	}
+	f.clauses = append(f.clauses, c)
+}
+
+// Exactly adds a constraint that exactly k of the literals are true
+func (f *Formula) Exactly(k int, lits []Literal) {
+	f.AtMost(k, lits)
+	negated := make([]Literal, len(lits))
+	for i, l := range lits {
+		negated[i] = -l
+	}
+	f.AtMost(len(lits)-k, negated)
+}
+
+// smallCardinality is the largest size encoded by plain combinations
+const smallCardinality = 10
+
+// AtMost adds a constraint that no more than k of the literals are true.
+// Short lists forbid every (k+1)-subset, longer ones use a sequential counter.
+func (f *Formula) AtMost(k int, lits []Literal) {
+	n := len(lits)
+	switch {
+	case k < 0:
+		f.empty = true
+	case k >= n:
+	case k == 0:
+		for _, l := range lits {
+			f.Add(-l)
+		}
+	case n <= smallCardinality:
+		subset := make([]Literal, 0, k+1)
+		var choose func(start int)
+		choose = func(start int) {
+			if len(subset) == k+1 {
+				f.Add(subset...)
+				return
+			}
+			for i := start; i < n; i++ {
+				subset = append(subset, -lits[i])
+				choose(i + 1)
+				subset = subset[:len(subset)-1]
+			}
+		}
+		choose(0)
+	default:
+		f.sequentialCounter(k, lits)
+	}
+}
+
+// sequentialCounter is the Sinz encoding: s[i][j] means at least j+1 of the first i+1 literals are true
+func (f *Formula) sequentialCounter(k int, lits []Literal) {
+	n := len(lits)
+	s := make([][]Literal, n-1)
+	for i := range s {
+		s[i] = make([]Literal, k)
+		for j := range s[i] {
+			s[i][j] = f.NewVar()
+		}
+	}
+	f.Add(-lits[0], s[0][0])
+	for j := 1; j < k; j++ {
+		f.Add(-s[0][j])
+	}
+	for i := 1; i < n-1; i++ {
+		f.Add(-lits[i], s[i][0])
+		f.Add(-s[i-1][0], s[i][0])
+		for j := 1; j < k; j++ {
+			f.Add(-lits[i], -s[i-1][j-1], s[i][j])
+			f.Add(-s[i-1][j], s[i][j])
+		}
+		f.Add(-lits[i], -s[i-1][k-1])
+	}
+	f.Add(-lits[n-1], -s[n-2][k-1])
+}
+
+// Solve looks for an assignment satisfying the formula and the assumptions.
+// The model is indexed by variable, index 0 is unused.
+func (f *Formula) Solve(assumptions ...Literal) ([]bool, bool) {
+	if f.empty {
+		return nil, false
+	}
+	s := newSolver(f)
+	for _, c := range f.clauses {
+		if len(c) == 1 && !s.enqueue(c[0]) {
+			return nil, false
+		}
+	}
+	for _, l := range assumptions {
+		if !s.enqueue(l) {
+			return nil, false
+		}
+	}
+	if !s.search() {
+		return nil, false
+	}
+	model := make([]bool, f.vars+1)
+	for v := 1; v <= f.vars; v++ {
+		model[v] = s.assign[v] > 0
+	}
+	return model, true
+}
+
+// solver is a DPLL search with two watched literals per clause
+type solver struct {
+	clauses [][]Literal
+	watches [][]int // clauses watching a literal, by literal code
+	assign  []int8  // 1 true, -1 false, 0 unassigned
+	trail   []Literal
+	head    int // first trail literal not propagated yet
+}
+
+func newSolver(f *Formula) *solver {
+	s := &solver{
+		watches: make([][]int, 2*f.vars+2),
+		assign:  make([]int8, f.vars+1),
+	}
+	for _, c := range f.clauses {
+		if len(c) < 2 {
+			continue
+		}
+		clause := append([]Literal(nil), c...) // watches reorder literals
+		s.clauses = append(s.clauses, clause)
+		ci := len(s.clauses) - 1
+		s.watches[clause[0].code()] = append(s.watches[clause[0].code()], ci)
+		s.watches[clause[1].code()] = append(s.watches[clause[1].code()], ci)
+	}
+	return s
+}
+
+func (s *solver) value(l Literal) int8 {
+	if l < 0 {
+		return -s.assign[-l]
+	}
+	return s.assign[l]
+}
+
+// enqueue makes a literal true, false means it is already false
+func (s *solver) enqueue(l Literal) bool {
+	switch s.value(l) {
+	case 1:
+		return true
+	case -1:
+		return false
+	}
+	if l < 0 {
+		s.assign[-l] = -1
+	} else {
+		s.assign[l] = 1
+	}
+	s.trail = append(s.trail, l)
+	return true
+}
+
+// propagate assigns unit literals until a fixpoint or a conflict
+func (s *solver) propagate() bool {
+	for s.head < len(s.trail) {
+		falseLit := -s.trail[s.head]
+		s.head++
+		watching := s.watches[falseLit.code()]
+		kept := watching[:0]
+		for i := 0; i < len(watching); i++ {
+			ci := watching[i]
+			c := s.clauses[ci]
+			if c[0] == falseLit {
+				c[0], c[1] = c[1], c[0]
+			}
+			if s.value(c[0]) == 1 {
+				kept = append(kept, ci)
+				continue
+			}
+			moved := false
+			for k := 2; k < len(c); k++ {
+				if s.value(c[k]) != -1 {
+					c[1], c[k] = c[k], c[1]
+					s.watches[c[1].code()] = append(s.watches[c[1].code()], ci)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+			kept = append(kept, ci)
+			if !s.enqueue(c[0]) {
+				kept = append(kept, watching[i+1:]...)
+				s.watches[falseLit.code()] = kept
+				return false
+			}
+		}
+		s.watches[falseLit.code()] = kept
+	}
+	return true
+}
+
+func (s *solver) undo(mark int) {
+	for len(s.trail) > mark {
+		l := s.trail[len(s.trail)-1]
+		s.trail = s.trail[:len(s.trail)-1]
+		s.assign[l.Var()] = 0
+	}
+	s.head = mark
+}
+
+func (s *solver) search() bool {
+	if !s.propagate() {
+		return false
+	}
+	v := 0
+	for i := 1; i < len(s.assign); i++ {
+		if s.assign[i] == 0 {
+			v = i
+			break
+		}
+	}
+	if v == 0 {
+		return true
+	}
+	mark := len(s.trail)
+	for _, l := range []Literal{-Literal(v), Literal(v)} {
+		s.enqueue(l)
+		if s.search() {
+			return true
+		}
+		s.undo(mark)
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package sat
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+// satisfies checks a full assignment, bit i of mask giving variable i+1, against clauses
+func satisfies(clauses [][]Literal, mask uint) bool {
+	for _, c := range clauses {
+		ok := false
+		for _, l := range c {
+			if (mask&(1<<uint(l.Var()-1)) != 0) == (l > 0) {
+				ok = true
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// assume fixes the first n variables to the bits of mask
+func assume(n int, mask uint) []Literal {
+	lits := make([]Literal, n)
+	for i := range lits {
+		lits[i] = Literal(i + 1)
+		if mask&(1<<uint(i)) == 0 {
+			lits[i] = -lits[i]
+		}
+	}
+	return lits
+}
+
+func newVars(f *Formula, n int) []Literal {
+	lits := make([]Literal, n)
+	for i := range lits {
+		lits[i] = f.NewVar()
+	}
+	return lits
+}
+
+func TestCardinality(t *testing.T) {
+	// plain combinations up to smallCardinality literals, a sequential counter beyond
+	for _, n := range []int{1, 3, smallCardinality, smallCardinality + 1, 13} {
+		for _, k := range []int{-1, 0, 1, 2, n / 2, n - 1, n, n + 1} {
+			for _, exactly := range []bool{false, true} {
+				var f Formula
+				lits := newVars(&f, n)
+				if exactly {
+					f.Exactly(k, lits)
+				} else {
+					f.AtMost(k, lits)
+				}
+				for mask := uint(0); mask < 1<<uint(n); mask++ {
+					count := bits.OnesCount(mask)
+					want := count <= k
+					if exactly {
+						want = count == k
+					}
+					if _, ok := f.Solve(assume(n, mask)...); ok != want {
+						t.Fatalf("%d of %d true, exactly %v %d: satisfiable %v, want %v", count, n, exactly, k, ok, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestUnsatisfiable(t *testing.T) {
+	var f Formula
+	x := f.NewVar()
+	f.Add(x)
+	f.Add(-x)
+	if _, ok := f.Solve(); ok {
+		t.Error("x and not x satisfied")
+	}
+
+	// four pigeons in three holes
+	var php Formula
+	in := make([][]Literal, 4)
+	for p := range in {
+		in[p] = newVars(&php, 3)
+		php.Exactly(1, in[p])
+	}
+	for h := 0; h < 3; h++ {
+		php.AtMost(1, []Literal{in[0][h], in[1][h], in[2][h], in[3][h]})
+	}
+	if _, ok := php.Solve(); ok {
+		t.Error("four pigeons fit in three holes")
+	}
+
+	// counters that cannot agree, on both sides of smallCardinality
+	for _, n := range []int{smallCardinality, smallCardinality + 5} {
+		var f Formula
+		lits := newVars(&f, n)
+		f.AtMost(2, lits)
+		f.Exactly(3, lits)
+		if _, ok := f.Solve(); ok {
+			t.Errorf("at most 2 and exactly 3 of %d satisfied", n)
+		}
+		var g Formula
+		lits = newVars(&g, n)
+		g.AtMost(n/2, lits)
+		negated := make([]Literal, n)
+		for i, l := range lits {
+			negated[i] = -l
+		}
+		g.AtMost(n/2-1, negated)
+		if _, ok := g.Solve(); ok {
+			t.Errorf("at most %d true and at most %d false of %d satisfied", n/2, n/2-1, n)
+		}
+	}
+
+	var empty Formula
+	empty.Add()
+	if _, ok := empty.Solve(); ok {
+		t.Error("empty clause satisfied")
+	}
+}
+
+func TestAssumptions(t *testing.T) {
+	var f Formula
+	a, b, c := f.NewVar(), f.NewVar(), f.NewVar()
+	f.Add(-a, b) // a implies b
+	f.Add(-b, c) // b implies c
+	model, ok := f.Solve(a)
+	if !ok || !model[a] || !model[b] || !model[c] {
+		t.Errorf("a assumed: model %v, %v", model, ok)
+	}
+	if _, ok := f.Solve(a, -c); ok {
+		t.Error("a and not c satisfied")
+	}
+	if _, ok := f.Solve(a, -a); ok {
+		t.Error("contradictory assumptions satisfied")
+	}
+	// assumptions do not stay in the formula
+	if model, ok := f.Solve(-c); !ok || model[a] || model[b] {
+		t.Errorf("not c assumed: model %v, %v", model, ok)
+	}
+}
+
+func TestRandomFormulas(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const vars = 8
+	for i := 0; i < 300; i++ {
+		var f Formula
+		newVars(&f, vars)
+		var clauses [][]Literal
+		for j := 0; j < 20+rng.Intn(20); j++ {
+			clause := make([]Literal, 1+rng.Intn(3))
+			for k := range clause {
+				clause[k] = Literal(1 + rng.Intn(vars))
+				if rng.Intn(2) == 0 {
+					clause[k] = -clause[k]
+				}
+			}
+			clauses = append(clauses, clause)
+			f.Add(clause...)
+		}
+		var assumptions []Literal
+		var fixed, values uint
+		for _, v := range rng.Perm(vars)[:rng.Intn(3)] {
+			fixed |= 1 << uint(v)
+			l := Literal(v + 1)
+			if rng.Intn(2) == 0 {
+				l = -l
+			} else {
+				values |= 1 << uint(v)
+			}
+			assumptions = append(assumptions, l)
+		}
+
+		want := false
+		for mask := uint(0); mask < 1<<vars; mask++ {
+			if mask&fixed == values && satisfies(clauses, mask) {
+				want = true
+				break
+			}
+		}
+		model, ok := f.Solve(assumptions...)
+		if ok != want {
+			t.Fatalf("formula %d: satisfiable %v, want %v", i, ok, want)
+		}
+		if !ok {
+			continue
+		}
+		var mask uint
+		for v := 1; v <= vars; v++ {
+			if model[v] {
+				mask |= 1 << uint(v-1)
+			}
+		}
+		if mask&fixed != values || !satisfies(clauses, mask) {
+			t.Fatalf("formula %d: model %v breaks the clauses or the assumptions", i, model)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package sim
+
+// Level is a board size with a number of mines
+type Level struct {
+	Name          string
+	Width, Height int
+	Mines         int
+}
+
+// Standard difficulty levels
+var (
+	Beginner     = Level{"beginner", 9, 9, 10}
+	Intermediate = Level{"intermediate", 16, 16, 40}
+	Expert       = Level{"expert", 30, 16, 99}
+)
+
+// Levels lists standard levels from easiest to hardest
+var Levels = []Level{Beginner, Intermediate, Expert}
+
+// NewBoard creates a board of the level
+func (l Level) NewBoard(seed int64) *Board {
+	return New(l.Width, l.Height, l.Mines, seed)
+}
@@ -0,0 +1,43 @@
+package sim
+
+import (
+	"image"
+
+	"../engine"
+)
+
+// Opening clicks a tile first on a number of fresh layouts and returns the share of zeros,
+// and the average number of tiles the first click opened
+func (l Level) Opening(at image.Point, rule engine.FirstClickRule, games int, seed int64) (rate, opened float64) {
+	board := l.NewBoard(seed)
+	board.FirstClick = rule
+	var zeros, tiles int
+	for i := 0; i < games; i++ {
+		board.Reset()
+		board.Reveal(at.X, at.Y)
+		if board.Status() == Lost {
+			continue
+		}
+		if board.Tile(at.X, at.Y) == engine.OpenSpace {
+			zeros++
+		}
+		tiles += board.width*board.height - board.mineCount - board.hidden
+	}
+	return float64(zeros) / float64(games), float64(tiles) / float64(games)
+}
+
+// LearnOpening measures openings of every tile in a quarter of the board, the rest mirroring it,
+// and returns the tile opening a zero most often with its rate, larger openings winning ties
+func (l Level) LearnOpening(rule engine.FirstClickRule, games int, seed int64) (image.Point, float64) {
+	var best image.Point
+	bestRate, bestOpened := -1.0, 0.0
+	for y := 0; y < (l.Height+1)/2; y++ {
+		for x := 0; x < (l.Width+1)/2; x++ {
+			rate, opened := l.Opening(image.Pt(x, y), rule, games, seed)
+			if rate > bestRate || rate == bestRate && opened > bestOpened {
+				best, bestRate, bestOpened = image.Pt(x, y), rate, opened
+			}
+		}
+	}
+	return best, bestRate
+}
@@ -0,0 +1,88 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"../engine"
+)
+
+// Result summarizes a series of simulated games
+type Result struct {
+	Games    int
+	Wins     int
+	Clicks   int
+	Moves    int
+	Guesses  int
+	Captures int
+	Duration time.Duration
+}
+
+// WinRate returns share of games won
+func (r Result) WinRate() float64 {
+	if r.Games == 0 {
+		return 0
+	}
+	return float64(r.Wins) / float64(r.Games)
+}
+
+// Interval returns the Wilson score interval of the win rate for a given z-score, 1.96 for 95%
+func (r Result) Interval(z float64) (lo, hi float64) {
+	if r.Games == 0 {
+		return 0, 1
+	}
+	n := float64(r.Games)
+	p := r.WinRate()
+	center := (p + z*z/(2*n)) / (1 + z*z/n)
+	spread := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / (1 + z*z/n)
+	return center - spread, center + spread
+}
+
+// perGame averages a counter over played games
+func (r Result) perGame(count int) float64 {
+	return float64(count) / float64(r.Games)
+}
+
+// MoveDuration returns average time the engine spent per move, screen reads included
+func (r Result) MoveDuration() time.Duration {
+	if r.Moves == 0 {
+		return 0
+	}
+	return r.Duration / time.Duration(r.Moves)
+}
+
+func (r Result) String() string {
+	if r.Games == 0 {
+		return "no games played"
+	}
+	lo, hi := r.Interval(1.96)
+	return fmt.Sprintf("won %d of %d (%.1f%%, 95%% CI %.1f–%.1f%%), "+
+		"%.2f guesses, %.1f clicks, %.1f captures per game, %v per move",
+		r.Wins, r.Games, 100*r.WinRate(), 100*lo, 100*hi,
+		r.perGame(r.Guesses), r.perGame(r.Clicks), r.perGame(r.Captures), r.MoveDuration())
+}
+
+// Play lets an engine built on top of the board play a number of games
+func Play(bot engine.Engine, board *Board, games int) (Result, error) {
+	var result Result
+	if err := bot.Start(); err != nil {
+		return result, err
+	}
+	start := time.Now()
+	for i := 0; i < games; i++ {
+		bot.StartGame()
+		bot.GameLoop()
+		stats := bot.Stats()
+		result.Games++
+		result.Clicks += board.Clicks
+		result.Moves += stats.Moves
+		result.Guesses += stats.Guesses
+		result.Captures += stats.Captures
+		if board.Status() == Won {
+			result.Wins++
+		}
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
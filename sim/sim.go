@@ -0,0 +1,370 @@
+package sim
+
+import (
+	"errors"
+	"image"
+	"math/rand"
+
+	"../engine"
+)
+
+// Status of a simulated game
+type Status int
+
+// Possible game statuses
+const (
+	Playing Status = iota
+	Won
+	Lost
+)
+
+func (s Status) String() string {
+	switch s {
+	case Won:
+		return "won"
+	case Lost:
+		return "lost"
+	default:
+		return "playing"
+	}
+}
+
+var errExploded = errors.New("Stepped on a mine")
+
+// Board is an in-memory Minesweeper game.
+// Mines are placed on the first reveal so that the first click is always safe.
+type Board struct {
+	width, height int
+	mineCount     int
+	rng           *rand.Rand
+	mines         [][]bool
+	visible       engine.Board
+	placed        bool
+	hidden        int // safe tiles not yet revealed
+	flags         int
+	counter       int // mines left as shown on the last field read
+	status        Status
+	layout        []image.Point // fixed mine positions, if any
+	Clicks        int
+	// FirstClick tells where mines cannot go on the first click, a safe tile by default
+	FirstClick engine.FirstClickRule
+}
+
+// New creates a board of a given size with a number of mines and a seed for mine placement
+func New(width, height, mines int, seed int64) *Board {
+	if mines > width*height-1 {
+		mines = width*height - 1
+	}
+	b := &Board{
+		width:     width,
+		height:    height,
+		mineCount: mines,
+		rng:       rand.New(rand.NewSource(seed)),
+	}
+	b.Reset()
+	return b
+}
+
+// NewWithLayout creates a board with mines at given positions, the same for every game
+func NewWithLayout(width, height int, mines []image.Point) *Board {
+	b := &Board{
+		width:     width,
+		height:    height,
+		mineCount: len(mines),
+		layout:    mines,
+	}
+	b.Reset()
+	return b
+}
+
+// Reset clears the board for a new game, next layout is drawn from the same seeded sequence
+func (b *Board) Reset() {
+	b.mines = makeGrid(b.width, b.height)
+	b.visible = engine.NewBoard(b.width, b.height)
+	b.placed = false
+	b.hidden = b.width*b.height - b.mineCount
+	b.flags = 0
+	b.counter = b.mineCount
+	b.status = Playing
+	b.Clicks = 0
+	if b.layout != nil {
+		for _, c := range b.layout {
+			b.mines[c.Y][c.X] = true
+		}
+		b.placed = true
+	}
+}
+
+func makeGrid(width, height int) [][]bool {
+	grid := make([][]bool, height)
+	cells := make([]bool, width*height)
+	for i := range grid {
+		grid[i], cells = cells[:width], cells[width:]
+	}
+	return grid
+}
+
+// placeMines scatters mines everywhere the first click rule allows around the first click
+func (b *Board) placeMines(safeX, safeY int) {
+	allowed := b.allowed(safeX, safeY, b.FirstClick)
+	if len(allowed) < b.mineCount {
+		allowed = b.allowed(safeX, safeY, engine.SafeTile)
+	}
+	perm := b.rng.Perm(len(allowed))
+	for _, i := range perm[:b.mineCount] {
+		b.mines[allowed[i]/b.width][allowed[i]%b.width] = true
+	}
+	b.placed = true
+}
+
+// allowed returns indices of tiles that can hold a mine with the first click at a given tile
+func (b *Board) allowed(safeX, safeY int, rule engine.FirstClickRule) []int {
+	result := make([]int, 0, b.width*b.height)
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			dx, dy := x-safeX, y-safeY
+			switch {
+			case rule == engine.SafeTile && dx == 0 && dy == 0:
+				continue
+			case rule == engine.SafeOpening && dx >= -1 && dx <= 1 && dy >= -1 && dy <= 1:
+				continue
+			}
+			result = append(result, y*b.width+x)
+		}
+	}
+	return result
+}
+
+// Layout returns positions of mines, nil until they are placed
+func (b *Board) Layout() []image.Point {
+	if !b.placed {
+		return nil
+	}
+	var result []image.Point
+	for y := range b.mines {
+		for x, mine := range b.mines[y] {
+			if mine {
+				result = append(result, image.Pt(x, y))
+			}
+		}
+	}
+	return result
+}
+
+// Width of the board
+func (b *Board) Width() int {
+	return b.width
+}
+
+// Height of the board
+func (b *Board) Height() int {
+	return b.height
+}
+
+// Mines returns total number of mines on the board
+func (b *Board) Mines() int {
+	return b.mineCount
+}
+
+// Status of the current game
+func (b *Board) Status() Status {
+	return b.status
+}
+
+// Tile returns a tile as a player sees it
+func (b *Board) Tile(x, y int) engine.Tile {
+	return b.visible[y][x]
+}
+
+func (b *Board) inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.width && y < b.height
+}
+
+func (b *Board) adjacentMines(x0, y0 int) int {
+	var count int
+	for y := y0 - 1; y <= y0+1; y++ {
+		for x := x0 - 1; x <= x0+1; x++ {
+			if b.inside(x, y) && b.mines[y][x] {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// reveal opens a tile, flood-filling through empty areas
+func (b *Board) reveal(x, y int) {
+	if b.status != Playing || !b.inside(x, y) || b.visible[y][x] != engine.Unknown {
+		return
+	}
+	if !b.placed {
+		b.placeMines(x, y)
+	}
+	if b.mines[y][x] {
+		b.explode()
+		b.visible[y][x] = engine.Bomb
+		return
+	}
+	stack := []int{y*b.width + x}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		cx, cy := i%b.width, i/b.width
+		if b.visible[cy][cx] != engine.Unknown {
+			continue
+		}
+		b.hidden--
+		count := b.adjacentMines(cx, cy)
+		if count > 0 {
+			b.visible[cy][cx] = engine.Tile(count)
+			continue
+		}
+		b.visible[cy][cx] = engine.OpenSpace
+		for ny := cy - 1; ny <= cy+1; ny++ {
+			for nx := cx - 1; nx <= cx+1; nx++ {
+				if b.inside(nx, ny) && b.visible[ny][nx] == engine.Unknown {
+					stack = append(stack, ny*b.width+nx)
+				}
+			}
+		}
+	}
+	if b.hidden == 0 {
+		b.win()
+	}
+}
+
+// ToggleFlag puts or removes a flag on an unopened tile
+func (b *Board) ToggleFlag(x, y int) {
+	if b.status != Playing || !b.inside(x, y) {
+		return
+	}
+	switch b.visible[y][x] {
+	case engine.Unknown:
+		b.visible[y][x] = engine.Flag
+		b.flags++
+	case engine.Flag:
+		b.visible[y][x] = engine.Unknown
+		b.flags--
+	}
+}
+
+// chord opens all unflagged neighbours of a number that has as many flags around it.
+// With a flag on the wrong tile a mine gets opened and the game is lost.
+func (b *Board) chord(x0, y0 int) {
+	if b.status != Playing || !b.inside(x0, y0) {
+		return
+	}
+	number := b.visible[y0][x0]
+	if number < 1 || number > 8 {
+		return
+	}
+	var flags int
+	for y := y0 - 1; y <= y0+1; y++ {
+		for x := x0 - 1; x <= x0+1; x++ {
+			if b.inside(x, y) && b.visible[y][x] == engine.Flag {
+				flags++
+			}
+		}
+	}
+	if flags != int(number) {
+		return
+	}
+	for y := y0 - 1; y <= y0+1; y++ {
+		for x := x0 - 1; x <= x0+1; x++ {
+			b.reveal(x, y)
+		}
+	}
+}
+
+func (b *Board) explode() {
+	b.status = Lost
+	for y := range b.mines {
+		for x, mine := range b.mines[y] {
+			if mine && b.visible[y][x] == engine.Unknown {
+				b.visible[y][x] = engine.Bomb
+			}
+		}
+	}
+}
+
+// win flags the remaining mines, as real games do
+func (b *Board) win() {
+	b.status = Won
+	for y := range b.mines {
+		for x, mine := range b.mines[y] {
+			if mine {
+				b.visible[y][x] = engine.Flag
+			}
+		}
+	}
+	b.flags = b.mineCount
+}
+
+// engine.Frontend implementation
+
+// Start reports board size to the engine
+func (b *Board) Start() (uint, uint, error) {
+	return uint(b.width), uint(b.height), nil
+}
+
+// NewGame starts a new game on a fresh layout
+func (b *Board) NewGame() {
+	b.Reset()
+}
+
+// ReadField copies visible tiles into the engine field
+func (b *Board) ReadField(field engine.Board, unknownsOnly bool) error {
+	for y := range field {
+		for x := range field[y] {
+			if unknownsOnly && field[y][x] != engine.Unknown {
+				continue
+			}
+			field[y][x] = b.visible[y][x]
+		}
+	}
+	b.counter = b.mineCount - b.flags
+	if b.status == Lost {
+		return errExploded
+	}
+	return nil
+}
+
+// GameState reports the game status to the engine
+func (b *Board) GameState() engine.GameState {
+	switch b.status {
+	case Won:
+		return engine.Won
+	case Lost:
+		return engine.Lost
+	}
+	return engine.InProgress
+}
+
+// Cleared reports that every safe tile is open. Unlike the zero on a mine counter
+// it is not fooled by flags on the wrong tiles.
+func (b *Board) Cleared() bool {
+	return b.hidden == 0
+}
+
+// MinesLeft works like a mine counter: total mines minus flags as of the last field read
+func (b *Board) MinesLeft() (int, bool) {
+	return b.counter, true
+}
+
+// Reveal opens a tile, flood-filling through empty areas
+func (b *Board) Reveal(x, y int) {
+	b.Clicks++
+	b.reveal(x, y)
+}
+
+// Chord opens neighbours of a number with all its flags in a single click
+func (b *Board) Chord(x, y int) {
+	b.Clicks++
+	b.chord(x, y)
+}
+
+// Flag toggles a flag on a tile
+func (b *Board) Flag(x, y int) {
+	b.Clicks++
+	b.ToggleFlag(x, y)
+}
@@ -0,0 +1,190 @@
+package sim
+
+import (
+	"image"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"../engine"
+)
+
+func TestRevealFloodFills(t *testing.T) {
+	b := NewWithLayout(5, 5, []image.Point{{4, 4}})
+	b.Reveal(0, 0)
+	if b.Status() != Won {
+		t.Fatalf("status %s, want won", b.Status())
+	}
+	field := engine.NewBoard(5, 5)
+	if err := b.ReadField(field, false); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		".....",
+		".....",
+		".....",
+		"...11",
+		"...1F",
+	}
+	for y, row := range field.Rows() {
+		if row != want[y] {
+			t.Errorf("row %d is %q, want %q", y, row, want[y])
+		}
+	}
+}
+
+func TestRevealMineLoses(t *testing.T) {
+	b := NewWithLayout(3, 3, []image.Point{{1, 1}})
+	b.Reveal(1, 1)
+	if b.Status() != Lost {
+		t.Fatalf("status %s, want lost", b.Status())
+	}
+	if b.Tile(1, 1) != engine.Bomb {
+		t.Errorf("tile %s, want a bomb", b.Tile(1, 1))
+	}
+	if err := b.ReadField(engine.NewBoard(3, 3), false); err == nil {
+		t.Error("reading a lost field succeeded")
+	}
+	if b.GameState() != engine.Lost {
+		t.Errorf("game state %v, want lost", b.GameState())
+	}
+}
+
+func TestFirstClickRules(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		b := New(9, 9, 10, seed)
+		b.Reveal(4, 4)
+		if b.Status() == Lost {
+			t.Fatalf("seed %d: safe first click lost", seed)
+		}
+		b = New(9, 9, 10, seed)
+		b.FirstClick = engine.SafeOpening
+		b.Reveal(0, 0)
+		if b.Tile(0, 0) != engine.OpenSpace {
+			t.Fatalf("seed %d: opening first click shows %s", seed, b.Tile(0, 0))
+		}
+	}
+	// with no room around the first click the opening rule falls back to a safe tile
+	b := New(3, 3, 8, 1)
+	b.FirstClick = engine.SafeOpening
+	b.Reveal(1, 1)
+	if b.Status() == Lost || b.Tile(1, 1) != 8 {
+		t.Errorf("crowded first click shows %s, status %s", b.Tile(1, 1), b.Status())
+	}
+}
+
+func TestMinesLeftAsOfLastRead(t *testing.T) {
+	b := NewWithLayout(4, 1, []image.Point{{0, 0}})
+	b.Flag(0, 0)
+	if mines, _ := b.MinesLeft(); mines != 1 {
+		t.Errorf("counter shows %d before a read, want 1", mines)
+	}
+	b.ReadField(engine.NewBoard(4, 1), false)
+	if mines, _ := b.MinesLeft(); mines != 0 {
+		t.Errorf("counter shows %d after a read, want 0", mines)
+	}
+}
+
+func TestClearedIgnoresWrongFlags(t *testing.T) {
+	b := NewWithLayout(4, 1, []image.Point{{0, 0}})
+	b.Flag(3, 0)
+	b.ReadField(engine.NewBoard(4, 1), false)
+	if b.Cleared() {
+		t.Fatal("a wrong flag cleared the board")
+	}
+	b.Reveal(2, 0)
+	b.Flag(3, 0)
+	b.Reveal(3, 0)
+	if !b.Cleared() || b.Status() != Won {
+		t.Errorf("cleared %v, status %s after opening every safe tile", b.Cleared(), b.Status())
+	}
+}
+
+func TestChord(t *testing.T) {
+	b := NewWithLayout(3, 3, []image.Point{{0, 0}, {2, 2}})
+	b.Reveal(1, 1)
+	b.Flag(0, 0)
+	b.Chord(1, 1)
+	if b.Status() != Playing || b.Tile(2, 0) != engine.Unknown {
+		t.Errorf("chord with a missing flag opened tiles")
+	}
+
+	b = NewWithLayout(3, 3, []image.Point{{0, 0}, {2, 2}})
+	b.Reveal(1, 1)
+	b.Flag(0, 0)
+	b.Flag(2, 2)
+	b.Chord(1, 1)
+	if b.Status() != Won {
+		t.Errorf("status %s after chording with both flags, want won", b.Status())
+	}
+
+	b = NewWithLayout(3, 3, []image.Point{{0, 0}, {2, 2}})
+	b.Reveal(1, 1)
+	b.Flag(0, 0)
+	b.Flag(2, 1)
+	b.Chord(1, 1)
+	if b.Status() != Lost {
+		t.Errorf("status %s after chording with a wrong flag, want lost", b.Status())
+	}
+}
+
+func TestPlayIsReproducible(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	play := func() Result {
+		board := Beginner.NewBoard(7)
+		bot := engine.NewEngine(board)
+		bot.SetSeed(7)
+		result, err := Play(bot, board, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	first, second := play(), play()
+	if first.Games != 50 {
+		t.Fatalf("played %d games, want 50", first.Games)
+	}
+	if first.Wins != second.Wins || first.Moves != second.Moves || first.Clicks != second.Clicks {
+		t.Errorf("runs differ: %v and %v", first, second)
+	}
+	if first.WinRate() < 0.5 {
+		t.Errorf("won %d of %d beginner games", first.Wins, first.Games)
+	}
+}
+
+// TestCapturesBatchMoves compares captures with moves: a loop capturing after every move
+// would need at least as many captures as moves
+func TestCapturesBatchMoves(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	for _, level := range Levels {
+		board := level.NewBoard(1)
+		bot := engine.NewEngine(board)
+		bot.SetSeed(1)
+		result, err := Play(bot, board, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%s: %d captures for %d moves", level.Name, result.Captures, result.Moves)
+		if result.Captures*2 >= result.Moves {
+			t.Errorf("%s: %d captures for %d moves, want fewer than half", level.Name, result.Captures, result.Moves)
+		}
+	}
+}
+
+func BenchmarkPlayExpert(b *testing.B) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	board := Expert.NewBoard(1)
+	bot := engine.NewEngine(board)
+	bot.SetSeed(1)
+	if err := bot.Start(); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		bot.StartGame()
+		bot.GameLoop()
+	}
+}
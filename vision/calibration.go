@@ -0,0 +1,215 @@
+package vision
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+
+	"github.com/jBugman/imghash"
+
+	"../engine"
+)
+
+// CalibrationVersion is the version of calibration files written by this code
+const CalibrationVersion = 1
+
+// Calibration is a learned set of tile and digit hashes replacing the compiled-in tables
+type Calibration struct {
+	Version int              `json:"version"`
+	Tiles   []CalibratedTile `json:"tiles"`
+	Digits  []CalibratedTile `json:"digits"`
+}
+
+// CalibratedTile labels a hash; tiles use the board text form, digits are 0-9.
+// A '?' tile stands for both unknown and open space, told apart by colour.
+type CalibratedTile struct {
+	Hash  string `json:"hash"`
+	Label string `json:"label"`
+}
+
+// LoadCalibration reads a calibration file
+func LoadCalibration(path string) (*Calibration, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var c Calibration
+	if err := json.NewDecoder(file).Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Version != CalibrationVersion {
+		return nil, fmt.Errorf("Calibration version %d is not supported, expected %d", c.Version, CalibrationVersion)
+	}
+	return &c, nil
+}
+
+// Save writes the calibration file
+func (c *Calibration) Save(path string) error {
+	c.Version = CalibrationVersion
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Add labels a hash of a tile or digit cluster
+func (c *Calibration) Add(cluster Cluster, label string) {
+	entry := CalibratedTile{Hash: fmt.Sprintf("%016X", uint64(cluster.Hash)), Label: label}
+	if cluster.Digit {
+		c.Digits = append(c.Digits, entry)
+	} else {
+		c.Tiles = append(c.Tiles, entry)
+	}
+}
+
+// Apply makes recognition use the calibrated hashes instead of the compiled-in ones
+func (c *Calibration) Apply() error {
+	tiles := map[ImageHash]engine.Tile{}
+	for _, t := range c.Tiles {
+		hash, err := parseHash(t.Hash)
+		if err != nil {
+			return err
+		}
+		board, err := engine.ParseBoard([]string{t.Label})
+		if err != nil || len(t.Label) != 1 {
+			return fmt.Errorf("Bad tile label %q", t.Label)
+		}
+		tiles[hash] = board[0][0]
+	}
+	digits := map[ImageHash]int{}
+	for _, d := range c.Digits {
+		hash, err := parseHash(d.Hash)
+		if err != nil {
+			return err
+		}
+		digit, err := strconv.Atoi(d.Label)
+		if err != nil || digit < 0 || digit > 9 {
+			return fmt.Errorf("Bad digit label %q", d.Label)
+		}
+		digits[hash] = digit
+	}
+	// a calibration of only tiles or only digits keeps the compiled-in table of the other kind
+	if len(tiles) > 0 {
+		setTileHashes(tiles)
+	}
+	if len(digits) > 0 {
+		setDigitHashes(digits)
+	}
+	return nil
+}
+
+func parseHash(s string) (ImageHash, error) {
+	hash, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Bad hash %q", s)
+	}
+	return ImageHash(hash), nil
+}
+
+// Cluster is a group of similar tile or counter digit images
+type Cluster struct {
+	Hash    ImageHash // hash of the first member
+	Digit   bool      // counter digit rather than a field tile
+	Members int
+	Sample  image.Image
+	Label   string // known from a labelled screenshot, empty otherwise
+}
+
+// ReferenceLabel labels a cluster after the compiled-in hash tables when it is close enough to one
+func (c Cluster) ReferenceLabel(maxDistance int) (string, bool) {
+	if c.Digit {
+		match, _, err := nearest(c.Hash, referenceDigitKeys(), maxDistance)
+		if err != nil {
+			return "", false
+		}
+		return strconv.Itoa(referenceDigitHashes[match.Hash]), true
+	}
+	match, _, err := nearest(c.Hash, referenceTileKeys(), maxDistance)
+	if err != nil {
+		return "", false
+	}
+	return engine.Board{{referenceTileHashes[match.Hash]}}.Rows()[0], true
+}
+
+// Clustering groups tiles of screenshots by hash: a hash within maxDistance
+// of an existing cluster joins it, otherwise it starts a new one
+type Clustering struct {
+	MaxDistance int
+	Clusters    []Cluster
+}
+
+func (c *Clustering) add(img image.Image, digit bool, label string) error {
+	hash := ImageHash(imghash.Average(img))
+	for i := range c.Clusters {
+		cluster := &c.Clusters[i]
+		if cluster.Digit == digit && Distance(cluster.Hash, hash) <= c.MaxDistance {
+			if label != "" && cluster.Label != "" && cluster.Label != label {
+				return fmt.Errorf("Hash %X is labelled both %q and %q", uint64(hash), cluster.Label, label)
+			}
+			if cluster.Label == "" {
+				cluster.Label = label
+			}
+			cluster.Members++
+			return nil
+		}
+	}
+	c.Clusters = append(c.Clusters, Cluster{Hash: hash, Digit: digit, Members: 1, Sample: img, Label: label})
+	return nil
+}
+
+// AddScreenshot collects tiles and counter digits of a whole window screenshot
+func (c *Clustering) AddScreenshot(img image.Image) error {
+	return c.addScreenshot(img, -1)
+}
+
+// AddCounterScreenshot collects tiles and counter digits of a whole window screenshot
+// whose counter shows a known value, labelling the digits with it. Screenshots of
+// a few counter values teach all ten digits without any reference hashes.
+func (c *Clustering) AddCounterScreenshot(img image.Image, counter int) error {
+	if counter < 0 {
+		return fmt.Errorf("Bad counter value %d", counter)
+	}
+	return c.addScreenshot(img, counter)
+}
+
+func (c *Clustering) addScreenshot(img image.Image, counter int) error {
+	v, rgba, err := fromWindow(img)
+	if err != nil {
+		return err
+	}
+	for y := uint(0); y < v.height; y++ {
+		for x := uint(0); x < v.width; x++ {
+			c.add(v.tileImage(rgba, x, y), false, "")
+		}
+	}
+	rest := counter
+	for n := uint(0); n < maxCounterDigits; n++ {
+		square := v.digitSquare(rgba, n)
+		if n > 0 && isBlank(square) {
+			break
+		}
+		// digits past the value are leading zeros
+		var label string
+		if counter >= 0 {
+			label = strconv.Itoa(rest % 10)
+			rest /= 10
+		}
+		if err := c.add(square, true, label); err != nil {
+			return err
+		}
+	}
+	if rest > 0 {
+		return fmt.Errorf("Counter shows fewer digits than %d", counter)
+	}
+	return nil
+}
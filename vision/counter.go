@@ -0,0 +1,170 @@
+package vision
+
+import (
+	"image"
+	"log"
+
+	"github.com/jBugman/imghash"
+)
+
+// Bomb counter geometry at nominal resolution: digits are right-aligned in the footer, one square per digit.
+// The timer is assumed to mirror it on the left side. That has not been measured on a capture yet,
+// corpus screenshots with a "timer N" line check it, and a timer that does not decode is not used.
+const (
+	counterTopMargin   = 9
+	counterRightMargin = 20
+	timerLeftMargin    = 20
+	digitSize          = 16
+	maxCounterDigits   = 3
+)
+
+// referenceDigitHashes maps average hashes of counter digits to their values.
+// Only zero is known, other digits are learned by calibrate from screenshots with known counter values,
+// until then the counters are not read and a zero counter is only recognized by its hash.
+var referenceDigitHashes = map[ImageHash]int{
+	zeroBombsHash: 0,
+}
+
+// digitHashes are used for recognition, a calibration may replace them
+var digitHashes = referenceDigitHashes
+
+// uncalibratedWarned keeps a missing digit calibration from being logged for every Vision
+var uncalibratedWarned bool
+
+// digitsCalibrated reports that the digit table knows all ten digits. The counters are not read
+// before that, as a digit missing from the table would be taken for the nearest known one.
+func digitsCalibrated() bool {
+	known := make(map[int]bool, 10)
+	for _, digit := range digitHashes {
+		known[digit] = true
+	}
+	return len(known) == 10
+}
+
+// digitSquare returns n-th digit square counting from the right
+func (v Vision) digitSquare(img *image.RGBA, n uint) image.Image {
+	field, size := v.grid.Field(), v.grid.scale(digitSize)
+	right := field.Max.X - v.grid.scale(counterRightMargin) - int(n)*size
+	top := field.Max.Y + v.grid.scale(counterTopMargin)
+	return img.SubImage(image.Rect(right-size, top, right, top+size))
+}
+
+// timerSquare returns n-th digit square of the timer counting from the left
+func (v Vision) timerSquare(img *image.RGBA, n uint) image.Image {
+	field, size := v.grid.Field(), v.grid.scale(digitSize)
+	left := field.Min.X + v.grid.scale(timerLeftMargin) + int(n)*size
+	top := field.Max.Y + v.grid.scale(counterTopMargin)
+	return img.SubImage(image.Rect(left, top, left+size, top+size))
+}
+
+// readTimer decodes seconds shown by the timer in the footer
+func (v *Vision) readTimer(img *image.RGBA) {
+	v.timerHash = ImageHash(imghash.Average(v.timerSquare(img, 0)))
+	if !digitsCalibrated() {
+		v.timerRead = false
+		return
+	}
+	var digits []int
+	for n := uint(0); n < maxCounterDigits; n++ {
+		square := v.timerSquare(img, n)
+		if n > 0 && isBlank(square) {
+			break
+		}
+		digit, err := v.decodeDigit(square)
+		if err != nil {
+			v.timerRead = false
+			return
+		}
+		digits = append(digits, digit)
+	}
+	v.seconds = 0
+	for _, digit := range digits {
+		v.seconds = v.seconds*10 + digit
+	}
+	v.timerRead = true
+}
+
+// decodeDigit matches a digit square with the nearest known hash
+func (v Vision) decodeDigit(square image.Image) (int, error) {
+	hash := ImageHash(imghash.Average(square))
+	match, close, err := nearest(hash, digitKeys(), v.MaxDistance)
+	if close != nil {
+		if resolved, ok := resolve(square, hash, match, close, digitHoles); ok {
+			match, err = resolved, nil
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return digitHashes[match.Hash], nil
+}
+
+// readCounter decodes the number of unflagged mines shown in the footer
+func (v *Vision) readCounter(img *image.RGBA) {
+	v.bombCountHash = ImageHash(imghash.Average(v.digitSquare(img, 0)))
+	if !digitsCalibrated() {
+		v.counterRead = false
+		if !v.counterWarned && !uncalibratedWarned {
+			v.counterWarned, uncalibratedWarned = true, true
+			log.Println("🔢 Counter digits are not calibrated, solving without the mine count")
+			log.Println("🔢 Teach them with calibrate screenshot.png=<counter value>")
+		}
+		return
+	}
+
+	value, err := v.decodeNumber(img)
+	v.minesLeft, v.counterRead = value, err == nil
+	if err != nil && !v.counterWarned {
+		v.counterWarned = true
+		log.Println("🔢 Cannot read the mine counter, solving without the mine count:", err)
+		log.Println("🔢 Teach its digits with calibrate screenshot.png=<counter value>")
+	}
+}
+
+func (v Vision) decodeNumber(img *image.RGBA) (int, error) {
+	var value int
+	multiplier := 1
+	for n := uint(0); n < maxCounterDigits; n++ {
+		square := v.digitSquare(img, n)
+		if n > 0 && isBlank(square) {
+			break
+		}
+		digit, err := v.decodeDigit(square)
+		if err != nil {
+			return 0, err
+		}
+		value += digit * multiplier
+		multiplier *= 10
+	}
+	return value, nil
+}
+
+// isBlank reports that an image is plain background without any glyph on it
+func isBlank(img image.Image) bool {
+	const threshold = 32
+	bounds := img.Bounds()
+	lo, hi := uint32(0xFFFF), uint32(0)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			lum := (r + g + b) / 3 >> 8
+			if lum < lo {
+				lo = lum
+			}
+			if lum > hi {
+				hi = lum
+			}
+		}
+	}
+	return hi-lo < threshold
+}
+
+// MinesLeft returns the decoded bomb counter
+func (v Vision) MinesLeft() (int, bool) {
+	return v.minesLeft, v.counterRead
+}
+
+// GameSeconds returns the decoded timer
+func (v Vision) GameSeconds() (int, bool) {
+	return v.seconds, v.timerRead
+}
@@ -0,0 +1,71 @@
+package vision
+
+import "testing"
+
+// learnDigits calibrates the digit table on synthetic counters, callers restore the tables
+func learnDigits(t *testing.T) {
+	// seven-segment digits differ by a single hash bit, so learning needs exact matches
+	clustering := Clustering{MaxDistance: 0}
+	// every digit shows up on one of these counters
+	for _, value := range []int{120, 345, 678, 900} {
+		w := newTestWindow(9, 9, 1)
+		w.setCounter(value)
+		if err := clustering.AddCounterScreenshot(w.img, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var calibration Calibration
+	for _, cluster := range clustering.Clusters {
+		if cluster.Label != "" {
+			calibration.Add(cluster, cluster.Label)
+		}
+	}
+	if len(calibration.Digits) != 10 {
+		t.Fatalf("learned %d digits, want 10", len(calibration.Digits))
+	}
+	if err := calibration.Apply(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCounterDigitsLearnedFromLabelledScreenshots(t *testing.T) {
+	defer restoreTables()()
+	learnDigits(t)
+
+	for _, value := range []int{0, 7, 42, 99, 305} {
+		w := newTestWindow(9, 9, 1)
+		w.setCounter(value)
+		w.vision.readCounter(w.img)
+		if mines, ok := w.vision.MinesLeft(); !ok || mines != value {
+			t.Errorf("counter showing %d read as %d, %v", value, mines, ok)
+		}
+	}
+}
+
+func TestCounterNotReadUntilCalibrated(t *testing.T) {
+	for _, value := range []int{0, 7, 10} {
+		w := newTestWindow(9, 9, 1)
+		w.setCounter(value)
+		w.vision.counterWarned = true
+		w.vision.readCounter(w.img)
+		if mines, ok := w.vision.MinesLeft(); ok {
+			t.Errorf("counter showing %d read as %d with only the built-in digits", value, mines)
+		}
+		w.vision.readTimer(w.img)
+		if seconds, ok := w.vision.GameSeconds(); ok {
+			t.Errorf("timer read as %d with only the built-in digits", seconds)
+		}
+	}
+}
+
+func TestCounterLabelsMustAgree(t *testing.T) {
+	clustering := Clustering{MaxDistance: DefaultMaxDistance}
+	w := newTestWindow(9, 9, 1)
+	w.setCounter(10)
+	if err := clustering.AddCounterScreenshot(w.img, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := clustering.AddCounterScreenshot(w.img, 11); err == nil {
+		t.Error("the same digit was labelled 0 and 1")
+	}
+}
@@ -0,0 +1,280 @@
+package vision
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// Grid is the tile lattice of the field on a window screenshot, in image pixels
+type Grid struct {
+	Origin        image.Point // top-left corner of the first tile
+	Pitch         int         // tile size
+	Columns, Rows int
+}
+
+// Limits of the tile size looked for by DetectGrid
+const (
+	minPitch = 8
+	maxPitch = 256
+)
+
+// DefaultGrid is the lattice of the macOS Minesweeper window at nominal resolution
+func DefaultGrid(windowWidth, windowHeight uint) Grid {
+	width, height := FieldSize(windowWidth, windowHeight)
+	return Grid{Origin: image.Pt(0, HeaderHeight), Pitch: TileSize, Columns: int(width), Rows: int(height)}
+}
+
+// Tile returns bounds of a tile
+func (g Grid) Tile(x, y int) image.Rectangle {
+	min := g.Origin.Add(image.Pt(x*g.Pitch, y*g.Pitch))
+	return image.Rectangle{min, min.Add(image.Pt(g.Pitch, g.Pitch))}
+}
+
+// Center returns the middle of a tile
+func (g Grid) Center(x, y int) image.Point {
+	return g.Origin.Add(image.Pt(x*g.Pitch+g.Pitch/2, y*g.Pitch+g.Pitch/2))
+}
+
+// Field returns bounds of all tiles
+func (g Grid) Field() image.Rectangle {
+	return image.Rectangle{g.Origin, g.Origin.Add(image.Pt(g.Columns*g.Pitch, g.Rows*g.Pitch))}
+}
+
+// scale converts a window distance measured at nominal resolution into pixels of this grid
+func (g Grid) scale(distance int) int {
+	return distance * g.Pitch / TileSize
+}
+
+func (g Grid) String() string {
+	return fmt.Sprintf("%dx%d tiles of %dpx at %d,%d", g.Columns, g.Rows, g.Pitch, g.Origin.X, g.Origin.Y)
+}
+
+// DetectGrid finds the tile lattice on a window screenshot. Tile borders make
+// brightness edges repeating at the tile pitch: the pitch is the strongest period
+// of edge profiles along both axes taken together, the phase aligns it with the edges,
+// and the field spans the longest run of strong lattice lines. Both axes share the pitch
+// as tiles are square, so a field of few rows or of mostly open tiles, whose glyphs make
+// edges of their own, is measured along its columns as well.
+func DetectGrid(img image.Image) (Grid, error) {
+	columns, rows := edgeProfiles(img)
+	pitch := period(columns, rows)
+	if pitch == 0 {
+		return Grid{}, errors.New("No repeating edges")
+	}
+	x0, nx, err := lattice(columns, pitch, true)
+	if err != nil {
+		return Grid{}, fmt.Errorf("Columns: %v", err)
+	}
+	y0, ny, err := lattice(rows, pitch, false)
+	if err != nil {
+		return Grid{}, fmt.Errorf("Rows: %v", err)
+	}
+	bounds := img.Bounds()
+	return Grid{Origin: image.Pt(x0-bounds.Min.X, y0-bounds.Min.Y), Pitch: pitch, Columns: nx, Rows: ny}, nil
+}
+
+// edgeProfiles counts pixels differing from their neighbour over every column and every row
+// of an image. Counting instead of summing the differences keeps faint borders between open
+// tiles on a par with bevels of unknown tiles and with contrasting glyphs, which only cover
+// part of a tile. A pixel counts a little more for a stronger difference, which tells apart
+// lines of a bevel crossing tiles equally often.
+func edgeProfiles(img image.Image) (columns, rows []float64) {
+	// brightness difference of an edge, out of 255
+	const edge = 8
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			lum[y*w+x] = float64(r+g+b) / 3 / 256
+		}
+	}
+	columns = make([]float64, w)
+	rows = make([]float64, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x > 0 {
+				if d := math.Abs(lum[y*w+x] - lum[y*w+x-1]); d >= edge {
+					columns[x] += 1 + d/255
+				}
+			}
+			if y > 0 {
+				if d := math.Abs(lum[y*w+x] - lum[(y-1)*w+x]); d >= edge {
+					rows[y] += 1 + d/255
+				}
+			}
+		}
+	}
+	return columns, rows
+}
+
+// lattice finds equally spaced edges in a profile: the position of the first one
+// and the number of tiles between the edges. The field may touch the image edges
+// along columns only.
+func lattice(profile []float64, pitch int, touching bool) (start, count int, err error) {
+	phase := latticePhase(profile, pitch)
+	// strength of every lattice line, allowing the pixel after it for borders drawn as two lines.
+	// The phase is at the first line already, so the pixel before it is left out: the window
+	// edge a pixel before the line below the field would extend the field.
+	// Edges of the image have no difference to measure. The window has no frame beside the field,
+	// so a field touching the image edge counts as bordered there when the tile next to the edge
+	// has edges of its own, unlike a plain background around the window. The title bar and the
+	// footer always keep the field off the image edges along the rows.
+	var lines []float64
+	var atEdge []int
+	for i := phase; i <= len(profile); i += pitch {
+		if i == 0 || i == len(profile) {
+			atEdge = append(atEdge, i)
+			lines = append(lines, 0)
+			continue
+		}
+		s := profile[i]
+		if i+1 < len(profile) {
+			s = math.Max(s, profile[i+1])
+		}
+		lines = append(lines, s)
+	}
+	sorted := append([]float64(nil), lines...)
+	sort.Float64s(sorted)
+	threshold := sorted[len(sorted)-1] / 8
+	for _, i := range atEdge {
+		if !touching || len(profile) < pitch {
+			continue
+		}
+		tile := profile[1:pitch]
+		if i > 0 {
+			tile = profile[i-pitch+1 : i]
+		}
+		for _, s := range tile {
+			if s >= threshold {
+				lines[(i-phase)/pitch] = threshold
+				break
+			}
+		}
+	}
+	runStart, runLength := 0, 0
+	for i := 0; i < len(lines); {
+		if lines[i] < threshold {
+			i++
+			continue
+		}
+		j := i
+		for j < len(lines) && lines[j] >= threshold {
+			j++
+		}
+		if j-i > runLength {
+			runStart, runLength = i, j-i
+		}
+		i = j
+	}
+	if runLength < 3 {
+		return 0, 0, errors.New("Too few tile borders")
+	}
+	return phase + runStart*pitch, runLength - 1, nil
+}
+
+// latticePhase returns the offset of lattice lines collecting the most edge strength.
+// The first pixel has no neighbour to differ from, so phases are compared by the mean
+// strength of their lines. A border drawn as two lines, like the top and left lines
+// of open tiles, makes neighbouring phases nearly as strong: the tile starts at the first.
+func latticePhase(profile []float64, pitch int) int {
+	scores := make([]float64, pitch)
+	for o := range scores {
+		var sum float64
+		var n int
+		for i := o; i < len(profile); i += pitch {
+			if i > 0 {
+				sum += profile[i]
+				n++
+			}
+		}
+		if n > 0 {
+			scores[o] = sum / float64(n)
+		}
+	}
+	phase := 0
+	for o, score := range scores {
+		if score > scores[phase] {
+			phase = o
+		}
+	}
+	best := scores[phase]
+	for n := 1; n < pitch; n++ {
+		previous := (phase + pitch - 1) % pitch
+		if scores[previous] < 0.97*best {
+			break
+		}
+		phase = previous
+	}
+	return phase
+}
+
+// period returns the spacing with the strongest autocorrelation of profiles taken together,
+// preferring the shortest one among nearly equal candidates, as multiples
+// of the tile size correlate as well. Every profile is scaled by its variance,
+// so that the axis with more tiles does not drown the other one.
+func period(profiles ...[]float64) int {
+	limit := maxPitch
+	for _, profile := range profiles {
+		if len(profile)/3 < limit {
+			limit = len(profile) / 3
+		}
+	}
+	if limit < minPitch {
+		return 0
+	}
+	ac := make([]float64, limit+2)
+	for _, profile := range profiles {
+		for lag, c := range autocorrelation(profile, limit+1) {
+			ac[lag] += c
+		}
+	}
+	top := 0.0
+	for lag := minPitch; lag <= limit; lag++ {
+		if ac[lag] > top {
+			top = ac[lag]
+		}
+	}
+	if top <= 0 {
+		return 0
+	}
+	for lag := minPitch; lag <= limit; lag++ {
+		if ac[lag] >= 0.8*top && ac[lag] >= ac[lag-1] && ac[lag] >= ac[lag+1] {
+			return lag
+		}
+	}
+	return 0
+}
+
+// autocorrelation returns the autocorrelation of a profile for lags up to a limit,
+// relative to its variance
+func autocorrelation(profile []float64, limit int) []float64 {
+	var mean float64
+	for _, v := range profile {
+		mean += v
+	}
+	mean /= float64(len(profile))
+	centered := make([]float64, len(profile))
+	for i, v := range profile {
+		centered[i] = v - mean
+	}
+	ac := make([]float64, limit+1)
+	for lag := range ac {
+		var sum float64
+		for i := 0; i+lag < len(centered); i++ {
+			sum += centered[i] * centered[i+lag]
+		}
+		ac[lag] = sum / float64(len(centered)-lag)
+	}
+	if ac[0] > 0 {
+		variance := ac[0]
+		for lag := range ac {
+			ac[lag] /= variance
+		}
+	}
+	return ac
+}
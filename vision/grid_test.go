@@ -0,0 +1,85 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"../engine"
+)
+
+// openedField is mostly open tiles, whose glyphs make edges off the lattice
+var openedField = []string{
+	"........",
+	"..1221..",
+	"..?FF?..",
+	"........",
+}
+
+// onScreen puts a window screenshot on a desktop at an offset
+func onScreen(img *image.RGBA, offset image.Point) *image.RGBA {
+	screen := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx()+offset.X+57, img.Bounds().Dy()+offset.Y+41))
+	fill(screen, screen.Bounds(), color.RGBA{40, 70, 110, 255})
+	draw.Draw(screen, img.Bounds().Add(offset), img, image.Point{}, draw.Src)
+	return screen
+}
+
+func TestDetectGrid(t *testing.T) {
+	fields := map[string][]string{
+		"fresh":  nil,
+		"mixed":  mixedField,
+		"opened": openedField,
+	}
+	offsets := []image.Point{{0, 0}, {13, 7}, {31, 95}, {100, 1}}
+	// 32px is the nominal tile, 64px and 96px are HiDPI, the others scale it by fractions
+	for _, pitch := range []int{32, 40, 48, 56, 64, 96} {
+		for name, rows := range fields {
+			for _, offset := range offsets {
+				w := newPitchWindow(8, 4, pitch)
+				if rows != nil {
+					w.setField(rows)
+				}
+				// footer glyphs make edges of their own
+				w.setCounter(678)
+				want := w.grid
+				want.Origin = want.Origin.Add(offset)
+				got, err := DetectGrid(onScreen(w.img, offset))
+				if err != nil {
+					t.Errorf("%s field of %dpx tiles at %v: %v", name, pitch, offset, err)
+				} else if got != want {
+					t.Errorf("%s field of %dpx tiles at %v: found %v, want %v", name, pitch, offset, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDetectGridBoardSizes(t *testing.T) {
+	for _, size := range [][2]int{{9, 9}, {16, 16}, {30, 16}} {
+		for _, scale := range []int{1, 2} {
+			w := newTestWindow(size[0], size[1], scale)
+			// open all but the last row, mostly to zeros
+			tiles := []engine.Tile{engine.OpenSpace, engine.OpenSpace, engine.OpenSpace, 1, 2, 3}
+			for y := 0; y < size[1]-1; y++ {
+				for x := 0; x < size[0]; x++ {
+					w.setTile(x, y, tiles[(x*7+y*3)%len(tiles)])
+				}
+			}
+			got, err := DetectGrid(w.img)
+			if err != nil {
+				t.Errorf("%dx%d at scale %d: %v", size[0], size[1], scale, err)
+			} else if got != w.grid {
+				t.Errorf("%dx%d at scale %d: found %v, want %v", size[0], size[1], scale, got, w.grid)
+			}
+		}
+	}
+}
+
+func TestDetectGridWithoutTiles(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	fill(img, img.Bounds(), backgroundColour)
+	if grid, err := DetectGrid(img); err == nil {
+		t.Errorf("found %v on a blank image", grid)
+	}
+}
@@ -0,0 +1,108 @@
+package vision
+
+import "image"
+
+// holeCounts are the numbers of closed loops in digit glyphs, the same in the usual fonts.
+// 4 is open in some fonts and closed in others, so it is not told apart by holes.
+var holeCounts = [10]int{0: 1, 1: 0, 2: 0, 3: 0, 4: -1, 5: 0, 6: 1, 7: 0, 8: 2, 9: 1}
+
+// inkDistance is the smallest difference from the background, summed over colour channels
+// in 0-255 levels, of a glyph pixel. Tile borders and bevel shades stay below it.
+const inkDistance = 120
+
+// countHoles counts the areas of background enclosed by the glyph on a tile or a digit square.
+// The background is the most common colour on its edges, areas of a few pixels are taken for noise.
+func countHoles(img image.Image) int {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return 0
+	}
+	background := borderColour(img)
+	ink := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			ink[y*w+x] = abs(int(r>>8)-background[0])+abs(int(g>>8)-background[1])+abs(int(b>>8)-background[2]) >= inkDistance
+		}
+	}
+
+	// background areas are 4-connected, so a glyph stroke closes them even along a diagonal
+	seen := make([]bool, w*h)
+	flood := func(start int) int {
+		size := 0
+		stack := []int{start}
+		seen[start] = true
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			size++
+			x, y := i%w, i/w
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n[0] < 0 || n[0] >= w || n[1] < 0 || n[1] >= h {
+					continue
+				}
+				j := n[1]*w + n[0]
+				if !ink[j] && !seen[j] {
+					seen[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+		return size
+	}
+	for x := 0; x < w; x++ {
+		for _, i := range []int{x, (h-1)*w + x} {
+			if !ink[i] && !seen[i] {
+				flood(i)
+			}
+		}
+	}
+	for y := 0; y < h; y++ {
+		for _, i := range []int{y * w, y*w + w - 1} {
+			if !ink[i] && !seen[i] {
+				flood(i)
+			}
+		}
+	}
+	minHole := w * h / 200
+	holes := 0
+	for i := range ink {
+		if !ink[i] && !seen[i] && flood(i) > minHole {
+			holes++
+		}
+	}
+	return holes
+}
+
+// borderColour returns the most common colour on the edges of an image in 0-255 levels,
+// glyphs leave a margin there while they may cover most of the inside
+func borderColour(img image.Image) [3]int {
+	bounds := img.Bounds()
+	counts := map[[3]int]int{}
+	var best [3]int
+	count := func(x, y int) {
+		r, g, b, _ := img.At(x, y).RGBA()
+		c := [3]int{int(r >> 8), int(g >> 8), int(b >> 8)}
+		counts[c]++
+		if counts[c] > counts[best] {
+			best = c
+		}
+	}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		count(x, bounds.Min.Y)
+		count(x, bounds.Max.Y-1)
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		count(bounds.Min.X, y)
+		count(bounds.Max.X-1, y)
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
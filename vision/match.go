@@ -0,0 +1,141 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+
+	"../engine"
+)
+
+// DefaultMaxDistance is the largest Hamming distance at which a hash still matches a known one.
+// Kept low as hashes of 6 and 8 differ in a single bit.
+const DefaultMaxDistance = 2
+
+// minMargin is how many bits closer an inexact nearest known hash has to be than the runner-up
+// to be taken on its own. Closer calls are told apart by the holes of the glyph.
+const minMargin = 2
+
+// Distance returns the number of differing bits of two hashes
+func Distance(a, b ImageHash) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+// Match is a known hash nearest to a recognized one
+type Match struct {
+	Hash     ImageHash
+	Distance int
+	// Confidence is 1 for an exact match and goes down to 0
+	// as the runner-up gets as close as the winner
+	Confidence float64
+}
+
+// nearest finds the known hash closest to a given one within maxDistance. An exact match is taken as is,
+// otherwise when another known hash is less than minMargin bits further there is no telling which one is right:
+// it fails and returns the close hashes within maxDistance, so that a caller can tell them apart otherwise.
+func nearest(hash ImageHash, known []ImageHash, maxDistance int) (Match, []ImageHash, error) {
+	best := Match{Distance: 65}
+	second := 65
+	for _, k := range known {
+		d := Distance(hash, k)
+		switch {
+		case d < best.Distance:
+			second = best.Distance
+			best.Hash, best.Distance = k, d
+		case d < second:
+			second = d
+		}
+	}
+	if best.Distance > maxDistance {
+		return best, nil, fmt.Errorf("Unknown hash: %X, nearest %X is %d bits away", hash, best.Hash, best.Distance)
+	}
+	best.Confidence = float64(second-best.Distance) / float64(second)
+	if best.Distance > 0 && second-best.Distance < minMargin {
+		var close []ImageHash
+		for _, k := range known {
+			if d := Distance(hash, k); d <= maxDistance && d-best.Distance < minMargin {
+				close = append(close, k)
+			}
+		}
+		return best, close, fmt.Errorf("Ambiguous hash: %X is within %d bits of %d known ones", hash, second, len(close))
+	}
+	return best, nil, nil
+}
+
+// resolve picks the only close hash whose value has as many holes as the glyph of an image,
+// holes returns -1 for values that may have any number of them
+func resolve(img image.Image, hash ImageHash, match Match, close []ImageHash, holes func(ImageHash) int) (Match, bool) {
+	n := countHoles(img)
+	found := 0
+	for _, k := range close {
+		if expected := holes(k); expected < 0 || expected == n {
+			match.Hash, match.Distance = k, Distance(hash, k)
+			found++
+		}
+	}
+	return match, found == 1
+}
+
+// Keys of the hash tables, built once per table instead of on every tile
+var (
+	tileKeyList           = tileKeysOf(tileHashes)
+	digitKeyList          = digitKeysOf(digitHashes)
+	referenceTileKeyList  = tileKeysOf(referenceTileHashes)
+	referenceDigitKeyList = digitKeysOf(referenceDigitHashes)
+)
+
+// setTileHashes replaces the table of tile hashes used for recognition
+func setTileHashes(table map[ImageHash]engine.Tile) {
+	tileHashes, tileKeyList = table, tileKeysOf(table)
+}
+
+// setDigitHashes replaces the table of digit hashes used for recognition
+func setDigitHashes(table map[ImageHash]int) {
+	digitHashes, digitKeyList = table, digitKeysOf(table)
+}
+
+func tileKeys() []ImageHash {
+	return tileKeyList
+}
+
+func referenceTileKeys() []ImageHash {
+	return referenceTileKeyList
+}
+
+func tileKeysOf(table map[ImageHash]engine.Tile) []ImageHash {
+	keys := make([]ImageHash, 0, len(table))
+	for h := range table {
+		keys = append(keys, h)
+	}
+	return keys
+}
+
+func digitKeys() []ImageHash {
+	return digitKeyList
+}
+
+func referenceDigitKeys() []ImageHash {
+	return referenceDigitKeyList
+}
+
+func digitKeysOf(table map[ImageHash]int) []ImageHash {
+	keys := make([]ImageHash, 0, len(table))
+	for h := range table {
+		keys = append(keys, h)
+	}
+	return keys
+}
+
+// tileHoles returns the holes of the glyph of a known tile hash
+func tileHoles(hash ImageHash) int {
+	tile := tileHashes[hash]
+	if tile < 1 || tile > 8 {
+		return -1
+	}
+	return holeCounts[tile]
+}
+
+// digitHoles returns the holes of the glyph of a known digit hash
+func digitHoles(hash ImageHash) int {
+	return holeCounts[digitHashes[hash]]
+}
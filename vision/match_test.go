@@ -0,0 +1,78 @@
+package vision
+
+import (
+	"image"
+	"testing"
+
+	"github.com/jBugman/imghash"
+
+	"../engine"
+)
+
+func TestNearestNeedsMargin(t *testing.T) {
+	known := []ImageHash{0x00, 0x03, 0xF0}
+	match, close, err := nearest(0x00, known, DefaultMaxDistance)
+	if err != nil || close != nil || match.Hash != 0x00 || match.Confidence != 1 {
+		t.Errorf("exact match %v, %v, %v", match, close, err)
+	}
+	match, close, err = nearest(0x01, known, DefaultMaxDistance)
+	if err == nil || len(close) != 2 {
+		t.Errorf("hash between two known ones matched %v, close %v", match, close)
+	}
+	// an exact match is taken even when another known hash is a bit away, like the built-in 6 and 8
+	six, eight := ImageHash(0xFFE7C3C3E3E3E7FF), ImageHash(0xFFE7C3C3E3C3E7FF)
+	for _, hash := range []ImageHash{six, eight} {
+		match, close, err = nearest(hash, referenceTileKeys(), DefaultMaxDistance)
+		if err != nil || close != nil || match.Hash != hash || match.Confidence != 1 {
+			t.Errorf("exact match of %X: %v, %v, %v", hash, match, close, err)
+		}
+	}
+	if _, close, err = nearest(0xFF, known, DefaultMaxDistance); err == nil || close != nil {
+		t.Errorf("distant hash matched, close %v", close)
+	}
+}
+
+func TestCountHoles(t *testing.T) {
+	for _, size := range []int{16, 32, 64} {
+		for digit, want := range holeCounts {
+			if want < 0 {
+				continue
+			}
+			img := image.NewRGBA(image.Rect(0, 0, size, size))
+			fill(img, img.Bounds(), openColour)
+			drawGlyph(img, img.Bounds(), digit, digitColour)
+			if got := countHoles(img); got != want {
+				t.Errorf("%d at %dpx has %d holes, want %d", digit, size, got, want)
+			}
+		}
+	}
+}
+
+func TestHolesTellSixFromEight(t *testing.T) {
+	defer restoreTables()()
+	w := newTestWindow(2, 1, 1)
+	w.setField([]string{"68"})
+	six, eight := w.img.SubImage(w.grid.Tile(0, 0)), w.img.SubImage(w.grid.Tile(1, 0))
+	hash := ImageHash(imghash.Average(six))
+
+	// exact matches stand, whatever the holes
+	setTileHashes(map[ImageHash]engine.Tile{hash: 8, hash ^ 1: 6})
+	if match, err := recognizeTile(six, DefaultMaxDistance); err != nil || match.Tile != 8 {
+		t.Errorf("exact match read as %s, %v", match.Tile, err)
+	}
+	// a bit away from one and two bits from the other: holes tell which one it is
+	setTileHashes(map[ImageHash]engine.Tile{hash ^ 1: 6, hash ^ 6: 8})
+	if match, err := recognizeTile(six, DefaultMaxDistance); err != nil || match.Tile != 6 {
+		t.Errorf("6 read as %s, %v", match.Tile, err)
+	}
+	// the nearest hash is the wrong one, holes overrule it
+	setTileHashes(map[ImageHash]engine.Tile{hash ^ 1: 8, hash ^ 6: 6})
+	if match, err := recognizeTile(six, DefaultMaxDistance); err != nil || match.Tile != 6 {
+		t.Errorf("6 read as %s, %v", match.Tile, err)
+	}
+	hash = ImageHash(imghash.Average(eight))
+	setTileHashes(map[ImageHash]engine.Tile{hash ^ 1: 6, hash ^ 6: 8})
+	if match, err := recognizeTile(eight, DefaultMaxDistance); err != nil || match.Tile != 8 {
+		t.Errorf("8 read as %s, %v", match.Tile, err)
+	}
+}
@@ -0,0 +1,203 @@
+package vision
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"../engine"
+)
+
+// FieldSize returns field size in tiles for a game window of a given size
+func FieldSize(windowWidth, windowHeight uint) (width, height uint) {
+	if windowHeight < HeaderHeight+FooterHeight {
+		return 0, 0
+	}
+	return windowWidth / TileSize, (windowHeight - HeaderHeight - FooterHeight) / TileSize
+}
+
+// Decode recognizes the field on a whole window screenshot, like the ones saved from captures.
+// It runs the same cropping and tile recognition as live play, at the scale that fits the size
+// of the window, and returns the field with recognition confidence of every tile.
+// Tiles are matched by hash when recognizer is nil.
+func Decode(img image.Image, maxDistance int, recognizer Recognizer) (engine.Board, [][]float64, error) {
+	v, rgba, err := fromWindow(img)
+	if err != nil {
+		return nil, nil, err
+	}
+	v.MaxDistance = maxDistance
+	v.Recognizer = recognizer
+	field := engine.NewBoard(int(v.width), int(v.height))
+	err = v.recognize(rgba, field, false)
+	return field, v.confidence, err
+}
+
+// DecodeFile recognizes the field on a PNG screenshot of the game window
+func DecodeFile(path string, maxDistance int, recognizer Recognizer) (engine.Board, [][]float64, error) {
+	img, err := LoadPNG(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Decode(img, maxDistance, recognizer)
+}
+
+// Footer holds the counters read on a window screenshot
+type Footer struct {
+	MinesLeft, Seconds   int
+	MinesRead, TimerRead bool
+}
+
+// DecodeFooter reads the mine counter and the timer on a whole window screenshot
+func DecodeFooter(img image.Image, maxDistance int) (Footer, error) {
+	v, rgba, err := fromWindow(img)
+	if err != nil {
+		return Footer{}, err
+	}
+	v.MaxDistance = maxDistance
+	v.counterWarned = true
+	v.readCounter(rgba)
+	v.readTimer(rgba)
+	return Footer{v.minesLeft, v.seconds, v.counterRead, v.timerRead}, nil
+}
+
+// Expected is what a corpus screenshot shows. The counters are nil when not written down.
+type Expected struct {
+	Field          engine.Board
+	Mines, Seconds *int
+}
+
+// ReadExpected reads the text file of the same name as a corpus screenshot: the field,
+// one row per line, optionally followed by "mines N" and "timer N" lines with the values
+// the counter and the timer show
+func ReadExpected(path string) (Expected, error) {
+	data, err := ioutil.ReadFile(strings.TrimSuffix(path, ".png") + ".txt")
+	if err != nil {
+		return Expected{}, err
+	}
+	var expected Expected
+	var rows []string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case len(fields) == 2 && (fields[0] == "mines" || fields[0] == "timer"):
+			value, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return Expected{}, fmt.Errorf("Bad %s value %q", fields[0], fields[1])
+			}
+			if fields[0] == "mines" {
+				expected.Mines = &value
+			} else {
+				expected.Seconds = &value
+			}
+		default:
+			rows = append(rows, fields...)
+		}
+	}
+	expected.Field, err = engine.ParseBoard(rows)
+	return expected, err
+}
+
+// CheckScreenshot decodes a corpus screenshot and compares it with what it is expected to show
+func CheckScreenshot(path string, maxDistance int, recognizer Recognizer) error {
+	expected, err := ReadExpected(path)
+	if err != nil {
+		return err
+	}
+	img, err := LoadPNG(path)
+	if err != nil {
+		return err
+	}
+	field, _, err := Decode(img, maxDistance, recognizer)
+	if err != nil {
+		return err
+	}
+	want := expected.Field
+	if want.Width() != field.Width() || want.Height() != field.Height() {
+		return fmt.Errorf("expected %dx%d field, got %dx%d",
+			want.Width(), want.Height(), field.Width(), field.Height())
+	}
+	var mismatches []string
+	for y := range want {
+		for x := range want[y] {
+			if want[y][x] != field[y][x] {
+				mismatches = append(mismatches, fmt.Sprintf("%d %d: expected %s, got %s", x, y, want[y][x], field[y][x]))
+			}
+		}
+	}
+	footer, err := DecodeFooter(img, maxDistance)
+	if err != nil {
+		return err
+	}
+	if expected.Mines != nil && (!footer.MinesRead || footer.MinesLeft != *expected.Mines) {
+		mismatches = append(mismatches, fmt.Sprintf("mines: expected %d, got %s", *expected.Mines, counterText(footer.MinesLeft, footer.MinesRead)))
+	}
+	if expected.Seconds != nil && (!footer.TimerRead || footer.Seconds != *expected.Seconds) {
+		mismatches = append(mismatches, fmt.Sprintf("timer: expected %d, got %s", *expected.Seconds, counterText(footer.Seconds, footer.TimerRead)))
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d differences\n  %s", len(mismatches), strings.Join(mismatches, "\n  "))
+	}
+	return nil
+}
+
+func counterText(value int, ok bool) string {
+	if !ok {
+		return "nothing readable"
+	}
+	return strconv.Itoa(value)
+}
+
+// WindowGrid detects the tile lattice on a whole window screenshot, falling back
+// to the macOS Minesweeper geometry at a given scale when detection fails
+func WindowGrid(img image.Image, scale Scale) (Grid, error) {
+	grid, err := DetectGrid(img)
+	if err == nil {
+		return grid, nil
+	}
+	bounds := img.Bounds()
+	points := scale.ToPoints(image.Pt(bounds.Dx(), bounds.Dy()))
+	grid = DefaultGrid(uint(points.X), uint(points.Y))
+	if grid.Columns == 0 || grid.Rows == 0 {
+		return grid, errors.New("Image is too small for a game window")
+	}
+	grid.Origin = scale.ToPixels(grid.Origin)
+	grid.Pitch = scale.ToPixels(image.Pt(grid.Pitch, 0)).X
+	return grid, nil
+}
+
+// fromWindow sizes Vision after a whole window screenshot and returns its game area as RGBA
+func fromWindow(img image.Image) (*Vision, *image.RGBA, error) {
+	// recognition expects RGBA pixels with the window origin at zero
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	// detection does not need the scale, the geometry it falls back to does
+	scale, ok := WindowScale(bounds.Dx(), bounds.Dy())
+	if !ok {
+		scale = 1
+	}
+	grid, err := WindowGrid(rgba, scale)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := NewWithGrid(grid)
+	return v, rgba.SubImage(v.Bounds().Intersect(rgba.Bounds())).(*image.RGBA), nil
+}
+
+// LoadPNG reads a PNG image
+func LoadPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
@@ -0,0 +1,175 @@
+package vision
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"../engine"
+)
+
+// corpusDir holds captures of the real game, see its README
+const corpusDir = "testdata/corpus"
+
+// mixedField has every tile value
+var mixedField = []string{
+	"1F2?????",
+	"12345678",
+	"........",
+	"??*?????",
+}
+
+// templatesOf cuts a template for every value of a drawn field
+func templatesOf(w *testWindow, field engine.Board) *TemplateRecognizer {
+	r := &TemplateRecognizer{MaxDistance: DefaultMaxTemplateDistance}
+	seen := map[engine.Tile]bool{}
+	for y := range field {
+		for x, tile := range field[y] {
+			if !seen[tile] {
+				seen[tile] = true
+				r.Templates = append(r.Templates, Template{tile, w.img.SubImage(w.grid.Tile(x, y))})
+			}
+		}
+	}
+	return r
+}
+
+// writeCorpusEntry saves a window screenshot and its expected field the way the corpus keeps them
+func writeCorpusEntry(t *testing.T, dir, name string, img image.Image, field engine.Board) string {
+	path := filepath.Join(dir, name+".png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Join(field.Rows(), "\n") + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".txt"), []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCorpus(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(corpusDir, "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skip("no captures in", corpusDir)
+	}
+	for _, path := range paths {
+		if err := CheckScreenshot(path, DefaultMaxDistance, nil); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestCheckScreenshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := newTestWindow(8, 4, 1)
+	field := w.setField(mixedField)
+	recognizer := templatesOf(w, field)
+	path := writeCorpusEntry(t, dir, "mixed", w.img, field)
+	if err := CheckScreenshot(path, DefaultMaxDistance, recognizer); err != nil {
+		t.Fatal(err)
+	}
+
+	field[0][0] = 2
+	writeCorpusEntry(t, dir, "mixed", w.img, field)
+	err = CheckScreenshot(path, DefaultMaxDistance, recognizer)
+	if err == nil || !strings.Contains(err.Error(), "1 differences") {
+		t.Errorf("error %v, want one differing tile", err)
+	}
+}
+
+func TestCheckScreenshotCounters(t *testing.T) {
+	defer restoreTables()()
+	learnDigits(t)
+	dir, err := ioutil.TempDir("", "corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := newTestWindow(8, 4, 1)
+	field := w.setField(mixedField)
+	w.setCounter(42)
+	w.setTimer(17)
+	recognizer := templatesOf(w, field)
+	path := writeCorpusEntry(t, dir, "counters", w.img, field)
+	footer, err := DecodeFooter(w.img, DefaultMaxDistance)
+	if err != nil || footer != (Footer{42, 17, true, true}) {
+		t.Fatalf("footer read as %+v, %v", footer, err)
+	}
+
+	for _, c := range []struct {
+		lines string
+		diffs int
+	}{
+		{"mines 42\ntimer 17\n", 0},
+		{"mines 41\n", 1},
+		{"mines 41\ntimer 7\n", 2},
+	} {
+		text := strings.Join(field.Rows(), "\n") + "\n" + c.lines
+		if err := ioutil.WriteFile(filepath.Join(dir, "counters.txt"), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := CheckScreenshot(path, DefaultMaxDistance, recognizer)
+		if c.diffs == 0 && err != nil {
+			t.Errorf("%q: %v", c.lines, err)
+		}
+		if c.diffs > 0 && (err == nil || !strings.HasPrefix(err.Error(), strconv.Itoa(c.diffs)+" differences")) {
+			t.Errorf("%q: error %v, want %d differences", c.lines, err, c.diffs)
+		}
+	}
+
+	expected, err := ReadExpected(path)
+	if err != nil || expected.Mines == nil || *expected.Mines != 41 || expected.Seconds == nil || *expected.Seconds != 7 {
+		t.Errorf("read %+v, %v", expected, err)
+	}
+}
+
+func TestDecodeLeavesNoFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "decode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	os.Mkdir("debug", 0755)
+
+	// hashes of the drawn tiles are unknown, so decoding fails on the way
+	w := newTestWindow(8, 4, 1)
+	w.setField(mixedField)
+	if _, _, err := Decode(w.img, DefaultMaxDistance, nil); err == nil {
+		t.Log("synthetic tiles matched the built-in hashes")
+	}
+	// nor are the digits of the counters
+	if _, err := DecodeFooter(w.img, DefaultMaxDistance); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := ioutil.ReadDir("debug")
+	if len(files) != 0 {
+		t.Errorf("decoding wrote %d files to debug", len(files))
+	}
+}
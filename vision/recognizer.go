@@ -0,0 +1,222 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+
+	"../engine"
+)
+
+// Recognizer tells the value of a single tile image
+type Recognizer interface {
+	RecognizeTile(tile image.Image) (TileMatch, error)
+}
+
+// TileMatch is a recognized tile value
+type TileMatch struct {
+	Tile engine.Tile
+	Match
+}
+
+// HashRecognizer matches average hashes of tiles, telling unknown tiles
+// from open space by colour as the hash does not see it
+type HashRecognizer struct {
+	MaxDistance int
+}
+
+// RecognizeTile matches a tile with the nearest known hash
+func (r HashRecognizer) RecognizeTile(tile image.Image) (TileMatch, error) {
+	return recognizeTile(tile, r.MaxDistance)
+}
+
+// DefaultMaxTemplateDistance is the largest RMS difference of colour channels,
+// in 0-255 levels, at which a tile still matches a template
+const DefaultMaxTemplateDistance = 40
+
+// Template is a reference image of a tile
+type Template struct {
+	Tile  engine.Tile
+	Image image.Image
+}
+
+// TemplateRecognizer compares tiles with reference images pixel by pixel in colour,
+// so unknown tiles and open space differ without special cases.
+// Match.Distance is the RMS difference of colour channels in 0-255 levels.
+type TemplateRecognizer struct {
+	Templates   []Template
+	MaxDistance int
+}
+
+// templateNames are file names of templates, the board text form does not make good names
+var templateNames = map[engine.Tile]string{
+	engine.Unknown:   "unknown",
+	engine.OpenSpace: "open",
+	engine.Flag:      "flag",
+	engine.Bomb:      "bomb",
+	1:                "1",
+	2:                "2",
+	3:                "3",
+	4:                "4",
+	5:                "5",
+	6:                "6",
+	7:                "7",
+	8:                "8",
+}
+
+// templateTiles lists tiles with templates in a fixed order, so that loading gives the same set every time
+var templateTiles = []engine.Tile{engine.Unknown, engine.OpenSpace, 1, 2, 3, 4, 5, 6, 7, 8, engine.Flag, engine.Bomb}
+
+// LoadTemplates reads templates saved by SaveTemplates from a directory
+func LoadTemplates(dir string) (*TemplateRecognizer, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("No templates: %v", err)
+	}
+	r := &TemplateRecognizer{MaxDistance: DefaultMaxTemplateDistance}
+	for _, tile := range templateTiles {
+		img, err := LoadPNG(filepath.Join(dir, templateNames[tile]+".png"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.Templates = append(r.Templates, Template{tile, img})
+	}
+	if len(r.Templates) < 2 {
+		return nil, fmt.Errorf("Too few templates in %s", dir)
+	}
+	return r, nil
+}
+
+// MissingTemplates returns the tiles without a template, in the order of templateTiles
+func MissingTemplates(templates []Template) []engine.Tile {
+	have := map[engine.Tile]bool{}
+	for _, t := range templates {
+		have[t.Tile] = true
+	}
+	var missing []engine.Tile
+	for _, tile := range templateTiles {
+		if !have[tile] {
+			missing = append(missing, tile)
+		}
+	}
+	return missing
+}
+
+// SaveTemplates writes one template per tile value to a directory
+func SaveTemplates(dir string, templates []Template) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, t := range templates {
+		file, err := os.Create(filepath.Join(dir, templateNames[t.Tile]+".png"))
+		if err != nil {
+			return err
+		}
+		err = png.Encode(file, t.Image)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractTemplates cuts the first tile of every value from a whole window screenshot
+// with a known field, such as a corpus capture. Labels come from the field rather than
+// from another recognizer, so templates also work where hashes do not.
+func ExtractTemplates(img image.Image, field engine.Board) ([]Template, error) {
+	v, rgba, err := fromWindow(img)
+	if err != nil {
+		return nil, err
+	}
+	if field.Width() != int(v.width) || field.Height() != int(v.height) {
+		return nil, fmt.Errorf("Field is %dx%d, the screenshot has %dx%d tiles",
+			field.Width(), field.Height(), v.width, v.height)
+	}
+	seen := map[engine.Tile]bool{}
+	var templates []Template
+	for y := uint(0); y < v.height; y++ {
+		for x := uint(0); x < v.width; x++ {
+			tile := field[y][x]
+			if seen[tile] {
+				continue
+			}
+			seen[tile] = true
+			templates = append(templates, Template{tile, v.tileImage(rgba, x, y)})
+		}
+	}
+	return templates, nil
+}
+
+// RecognizeTile matches a tile with the closest template
+func (r TemplateRecognizer) RecognizeTile(tile image.Image) (TileMatch, error) {
+	best, second := -1, math.Inf(1)
+	bestDistance := math.Inf(1)
+	for i, t := range r.Templates {
+		d := colourDistance(tile, t.Image)
+		switch {
+		case d < bestDistance:
+			second = bestDistance
+			best, bestDistance = i, d
+		case d < second:
+			second = d
+		}
+	}
+	match := TileMatch{engine.Unknown, Match{Distance: int(math.Round(bestDistance))}}
+	if best < 0 || bestDistance > float64(r.MaxDistance) {
+		return match, fmt.Errorf("Unknown tile: nearest template is %.1f levels away", bestDistance)
+	}
+	match.Tile = r.Templates[best].Tile
+	if math.IsInf(second, 1) {
+		match.Confidence = 1
+	} else if second > 0 {
+		match.Confidence = (second - bestDistance) / second
+	}
+	return match, nil
+}
+
+// Separation returns the smallest distance between templates of different tiles.
+// Recognition is reliable while it stays well above MaxDistance.
+func (r TemplateRecognizer) Separation() float64 {
+	separation := math.Inf(1)
+	for i, a := range r.Templates {
+		for _, b := range r.Templates[i+1:] {
+			if a.Tile != b.Tile {
+				separation = math.Min(separation, colourDistance(a.Image, b.Image))
+			}
+		}
+	}
+	return separation
+}
+
+// colourDistance is the RMS difference of colour channels of two images in 0-255 levels.
+// Pixels of the template are compared with the same relative position on the tile,
+// so templates taken on one display density still apply to another.
+func colourDistance(tile, template image.Image) float64 {
+	tb, pb := tile.Bounds(), template.Bounds()
+	if tb.Empty() || pb.Empty() {
+		return math.Inf(1)
+	}
+	var sum float64
+	for y := 0; y < pb.Dy(); y++ {
+		ty := tb.Min.Y + y*tb.Dy()/pb.Dy()
+		for x := 0; x < pb.Dx(); x++ {
+			tx := tb.Min.X + x*tb.Dx()/pb.Dx()
+			r1, g1, b1, _ := tile.At(tx, ty).RGBA()
+			r2, g2, b2, _ := template.At(pb.Min.X+x, pb.Min.Y+y).RGBA()
+			for _, d := range [3]float64{
+				float64(r1>>8) - float64(r2>>8),
+				float64(g1>>8) - float64(g2>>8),
+				float64(b1>>8) - float64(b2>>8),
+			} {
+				sum += d * d
+			}
+		}
+	}
+	return math.Sqrt(sum / float64(3*pb.Dx()*pb.Dy()))
+}
@@ -0,0 +1,129 @@
+package vision
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"../engine"
+)
+
+// corpusTemplates cuts templates from every capture of the corpus
+func corpusTemplates(t *testing.T, paths []string) *TemplateRecognizer {
+	r := &TemplateRecognizer{MaxDistance: DefaultMaxTemplateDistance}
+	found := map[engine.Tile]bool{}
+	for _, path := range paths {
+		img, err := LoadPNG(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := ReadExpected(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		templates, err := ExtractTemplates(img, expected.Field)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		for _, template := range templates {
+			if !found[template.Tile] {
+				found[template.Tile] = true
+				r.Templates = append(r.Templates, template)
+			}
+		}
+	}
+	return r
+}
+
+func TestTemplatesSeparateCorpusTiles(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(corpusDir, "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Skip("no captures in", corpusDir)
+	}
+	r := corpusTemplates(t, paths)
+	if missing := MissingTemplates(r.Templates); len(missing) > 0 {
+		t.Logf("the corpus shows no %v", missing)
+	}
+	if separation := r.Separation(); separation <= float64(r.MaxDistance) {
+		t.Errorf("templates are %.1f levels apart, matching within %d", separation, r.MaxDistance)
+	}
+	for _, path := range paths {
+		if err := CheckScreenshot(path, DefaultMaxDistance, r); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestTemplatesFromLabelledScreenshot(t *testing.T) {
+	w := newTestWindow(8, 4, 1)
+	field := w.setField(mixedField)
+	templates, err := ExtractTemplates(w.img, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &TemplateRecognizer{Templates: templates, MaxDistance: DefaultMaxTemplateDistance}
+	if missing := MissingTemplates(templates); len(missing) > 0 {
+		t.Fatalf("no templates for %v", missing)
+	}
+	if separation := r.Separation(); separation <= float64(r.MaxDistance) {
+		t.Errorf("templates are %.1f levels apart, matching within %d", separation, r.MaxDistance)
+	}
+
+	// tiles in other places
+	w = newTestWindow(8, 4, 1)
+	field = w.setField([]string{
+		"87654321",
+		"?F*.....",
+		"..1?2F3*",
+		"????????",
+	})
+	decoded, _, err := Decode(w.img, DefaultMaxDistance, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Rows(), field.Rows()) {
+		t.Errorf("decoded\n%s\nwant\n%s", decoded, field)
+	}
+
+	if _, err := ExtractTemplates(w.img, engine.NewBoard(3, 3)); err == nil {
+		t.Error("templates cut with a field of the wrong size")
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := LoadTemplates(filepath.Join(dir, "missing")); err == nil {
+		t.Error("loaded templates from a missing directory")
+	}
+
+	w := newTestWindow(8, 4, 1)
+	templates, err := ExtractTemplates(w.img, w.setField(mixedField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveTemplates(dir, templates); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		r, err := LoadTemplates(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tiles []engine.Tile
+		for _, template := range r.Templates {
+			tiles = append(tiles, template.Tile)
+		}
+		if !reflect.DeepEqual(tiles, templateTiles) {
+			t.Fatalf("loaded templates in order %v, want %v", tiles, templateTiles)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package vision
+
+import (
+	"image"
+	"math"
+)
+
+// Scale is the number of image pixels per screen point: 1 on regular displays,
+// 2 on Retina, fractional on scaled display modes
+type Scale float64
+
+// ScaleOf compares the width of a captured image with the width of the window in points
+func ScaleOf(imageWidth int, windowWidth uint) Scale {
+	if imageWidth <= 0 || windowWidth == 0 {
+		return 1
+	}
+	return Scale(float64(imageWidth) / float64(windowWidth))
+}
+
+// ToPoints converts a position on the image into window points
+func (s Scale) ToPoints(p image.Point) image.Point {
+	return image.Pt(int(math.Floor(float64(p.X)/float64(s))), int(math.Floor(float64(p.Y)/float64(s))))
+}
+
+// ToPixels converts a position in window points into the first image pixel of the point,
+// so that ToPoints takes it back to the same point at fractional scales too
+func (s Scale) ToPixels(p image.Point) image.Point {
+	const slack = 1e-9 // products that should be whole numbers but come out a hair above
+	return image.Pt(int(math.Ceil(float64(p.X)*float64(s)-slack)), int(math.Ceil(float64(p.Y)*float64(s)-slack)))
+}
+
+// windowScales are the display densities tried by WindowScale, usual ones first
+var windowScales = []Scale{1, 2, 1.5, 1.25, 1.75, 3}
+
+// WindowScale finds the scale of a whole window screenshot of the macOS Minesweeper from its size,
+// as saved screenshots do not tell it: the one at which the window is a whole number of tiles across
+// and, besides the header and footer, down. It fails when no usual scale fits.
+func WindowScale(width, height int) (Scale, bool) {
+	for _, s := range windowScales {
+		tile := float64(s) * TileSize
+		columns := math.Round(float64(width) / tile)
+		rows := math.Round((float64(height) - float64(s)*(HeaderHeight+FooterHeight)) / tile)
+		if columns < 1 || rows < 1 {
+			continue
+		}
+		if math.Abs(columns*tile-float64(width)) <= 1 &&
+			math.Abs(float64(s)*(HeaderHeight+FooterHeight)+rows*tile-float64(height)) <= 1 {
+			return s, true
+		}
+	}
+	return 1, false
+}
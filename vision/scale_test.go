@@ -0,0 +1,79 @@
+package vision
+
+import (
+	"image"
+	"testing"
+)
+
+var testScales = []Scale{1, 2, 1.5, 1.25, 1.75}
+
+func TestScaleRoundTrip(t *testing.T) {
+	for _, s := range testScales {
+		for _, p := range []image.Point{{0, 0}, {1, 1}, {17, 3}, {479, 255}, {1023, 767}} {
+			if got := s.ToPoints(s.ToPixels(p)); got != p {
+				t.Errorf("scale %v: %v came back as %v", s, p, got)
+			}
+			// every pixel up to the next point belongs to this one
+			for x := s.ToPixels(p).X; x < s.ToPixels(p.Add(image.Pt(1, 0))).X; x++ {
+				if got := s.ToPoints(image.Pt(x, 0)).X; got != p.X {
+					t.Errorf("scale %v: pixel %d of point %d maps to %d", s, x, p.X, got)
+				}
+			}
+		}
+	}
+}
+
+func TestScaleOf(t *testing.T) {
+	if s := ScaleOf(960, 480); s != 2 {
+		t.Errorf("scale %v, want 2", s)
+	}
+	if s := ScaleOf(720, 480); s != 1.5 {
+		t.Errorf("scale %v, want 1.5", s)
+	}
+	if s := ScaleOf(0, 0); s != 1 {
+		t.Errorf("scale of an empty window %v, want 1", s)
+	}
+}
+
+func TestWindowScale(t *testing.T) {
+	for _, s := range testScales {
+		for _, size := range [][2]int{{9, 9}, {16, 16}, {30, 16}} {
+			points := image.Pt(size[0]*TileSize, HeaderHeight+FooterHeight+size[1]*TileSize)
+			pixels := s.ToPixels(points)
+			got, ok := WindowScale(pixels.X, pixels.Y)
+			if !ok || got != s {
+				t.Errorf("%dx%d window at scale %v: found %v, %v", size[0], size[1], s, got, ok)
+			}
+		}
+	}
+	if _, ok := WindowScale(100, 100); ok {
+		t.Error("found a scale for a window of no tiles")
+	}
+}
+
+func TestDecodeAtScale(t *testing.T) {
+	w := newTestWindow(8, 4, 1)
+	templates, err := ExtractTemplates(w.img, w.setField(mixedField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &TemplateRecognizer{Templates: templates, MaxDistance: DefaultMaxTemplateDistance}
+	for _, scale := range []int{1, 2, 3} {
+		w := newTestWindow(8, 4, scale)
+		field := w.setField(mixedField)
+		// the lattice is detected, not taken from the window size
+		if grid, err := DetectGrid(w.img); err != nil || grid != w.grid {
+			t.Errorf("scale %d: detected %v, %v, want %v", scale, grid, err, w.grid)
+		}
+		decoded, _, err := Decode(w.img, DefaultMaxDistance, r)
+		if err != nil {
+			t.Errorf("scale %d: %v", scale, err)
+			continue
+		}
+		for y, row := range decoded.Rows() {
+			if want := field.Rows()[y]; row != want {
+				t.Errorf("scale %d: row %d is %q, want %q", scale, y, row, want)
+			}
+		}
+	}
+}
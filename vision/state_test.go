@@ -0,0 +1,100 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"../engine"
+)
+
+// setMessage draws a stand-in for the end of game message in the middle of the field,
+// a dark box with a light line of text and a light close button in the top right corner,
+// the features messageMask looks for
+func (w *testWindow) setMessage() {
+	field := w.grid.Field()
+	center := field.Min.Add(field.Max).Div(2)
+	half := image.Pt(w.grid.scale(restartMessageW)/2, w.grid.scale(restartMessageH)/2)
+	r := image.Rectangle{center.Sub(half), center.Add(half)}
+	light := color.RGBA{250, 250, 250, 255}
+	fill(w.img, r, color.RGBA{60, 60, 70, 255})
+	fill(w.img, image.Rect(r.Min.X+r.Dx()/8, r.Min.Y+r.Dy()*3/8, r.Max.X-r.Dx()/8, r.Min.Y+r.Dy()*5/8), light)
+	fill(w.img, image.Rect(r.Max.X-r.Dx()/4, r.Min.Y, r.Max.X, r.Min.Y+r.Dy()/8), light)
+}
+
+func TestGameState(t *testing.T) {
+	tiles := newTestWindow(8, 4, 1)
+	recognizer := templatesOf(tiles, tiles.setField(mixedField))
+	for _, c := range []struct {
+		name    string
+		field   []string
+		message bool
+		want    engine.GameState
+	}{
+		{"fresh", nil, false, engine.InProgress},
+		{"opened", []string{
+			"?1.......",
+			"?1.......",
+			"11.......",
+			".........",
+			".........",
+			".........",
+			"......111",
+			"......1??",
+			"......1??",
+		}, false, engine.InProgress},
+		{"lost", []string{
+			"?1.......",
+			"*1.......",
+			"11.......",
+			".........",
+			".........",
+			".........",
+			"......111",
+			"......1?*",
+			"......1??",
+		}, false, engine.Lost},
+		{"won", []string{
+			"F1.......",
+			"?1.......",
+			"11.......",
+			".........",
+			".........",
+			".........",
+			"......111",
+			"......1F?",
+			"......1??",
+		}, true, engine.Won},
+	} {
+		w := newTestWindow(9, 9, 1)
+		if c.field != nil {
+			w.setField(c.field)
+		}
+		if c.message {
+			w.setMessage()
+		}
+		w.vision.Recognizer = recognizer
+		w.vision.recognize(w.img, engine.NewBoard(9, 9), false)
+		if got := w.vision.GameState(); got != c.want {
+			t.Errorf("%s: state %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestMessageNotSeenOnBoards(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tiles := []engine.Tile{engine.Unknown, engine.OpenSpace, engine.OpenSpace, 1, 2, 3, engine.Flag}
+	for i := 0; i < 100; i++ {
+		w := newTestWindow(9, 9, 1)
+		for y := 0; y < 9; y++ {
+			for x := 0; x < 9; x++ {
+				w.setTile(x, y, tiles[rng.Intn(len(tiles))])
+			}
+		}
+		w.vision.readMessage(w.img)
+		if w.vision.message {
+			t.Fatalf("board %d taken for the end of game message", i)
+		}
+	}
+}
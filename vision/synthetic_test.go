@@ -0,0 +1,165 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"../engine"
+)
+
+// Synthetic window screenshots in the macOS Minesweeper layout. They exercise the geometry,
+// decoding and learning code; hashes of real game captures come from the corpus instead.
+
+var (
+	backgroundColour = color.RGBA{200, 200, 200, 255}
+	digitColour      = color.RGBA{40, 40, 40, 255}
+	unknownColour    = color.RGBA{120, 90, 190, 255}
+	lightBevelColour = color.RGBA{170, 140, 230, 255}
+	darkBevelColour  = color.RGBA{80, 50, 140, 255}
+	openColour       = color.RGBA{235, 235, 240, 255}
+	openLineColour   = color.RGBA{215, 215, 220, 255}
+	numberColours    = []color.RGBA{
+		1: {30, 60, 220, 255},
+		2: {30, 140, 30, 255},
+		3: {220, 30, 30, 255},
+		4: {30, 30, 130, 255},
+		5: {130, 30, 30, 255},
+		6: {0, 128, 128, 255},
+		7: {20, 20, 20, 255},
+		8: {110, 110, 110, 255},
+	}
+)
+
+// segments of seven-segment glyphs: top, top right, bottom right, bottom, bottom left, top left, middle
+var glyphSegments = []string{"abcdef", "bc", "abdeg", "abcdg", "bcfg", "acdfg", "acdefg", "abc", "abcdefg", "abcdfg"}
+
+// drawGlyph draws a seven-segment digit in a square
+func drawGlyph(img *image.RGBA, r image.Rectangle, digit int, c color.RGBA) {
+	w, h := r.Dx(), r.Dy()
+	x0, x1 := r.Min.X+w/8, r.Max.X-w/8
+	y0, y1 := r.Min.Y+h/16, r.Max.Y-h/16
+	t := w / 6
+	ym := (y0 + y1) / 2
+	segments := map[rune]image.Rectangle{
+		'a': image.Rect(x0, y0, x1, y0+t),
+		'b': image.Rect(x1-t, y0, x1, ym),
+		'c': image.Rect(x1-t, ym, x1, y1),
+		'd': image.Rect(x0, y1-t, x1, y1),
+		'e': image.Rect(x0, ym, x0+t, y1),
+		'f': image.Rect(x0, y0, x0+t, ym),
+		'g': image.Rect(x0, ym-t/2, x1, ym-t/2+t),
+	}
+	for _, s := range glyphSegments[digit] {
+		fill(img, segments[s], c)
+	}
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// testWindow is a synthetic screenshot of the whole game window
+type testWindow struct {
+	img    *image.RGBA
+	grid   Grid
+	vision *Vision
+}
+
+// newTestWindow draws a window of unknown tiles at a whole number of pixels per point
+func newTestWindow(columns, rows, scale int) *testWindow {
+	return newPitchWindow(columns, rows, TileSize*scale)
+}
+
+// newPitchWindow draws a window of unknown tiles of any size in pixels
+func newPitchWindow(columns, rows, pitch int) *testWindow {
+	header, footer := HeaderHeight*pitch/TileSize, FooterHeight*pitch/TileSize
+	grid := Grid{Origin: image.Pt(0, header), Pitch: pitch, Columns: columns, Rows: rows}
+	img := image.NewRGBA(image.Rect(0, 0, columns*pitch, header+footer+rows*pitch))
+	fill(img, img.Bounds(), backgroundColour)
+	w := &testWindow{img: img, grid: grid, vision: NewWithGrid(grid)}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			w.setTile(x, y, engine.Unknown)
+		}
+	}
+	return w
+}
+
+// setTile draws a tile
+func (w *testWindow) setTile(x, y int, tile engine.Tile) {
+	r := w.grid.Tile(x, y)
+	bevel := w.grid.Pitch / 16
+	switch {
+	case tile == engine.Unknown || tile == engine.Flag:
+		fill(w.img, r, darkBevelColour)
+		fill(w.img, image.Rectangle{r.Min, r.Max.Sub(image.Pt(bevel, bevel))}, lightBevelColour)
+		fill(w.img, r.Inset(bevel), unknownColour)
+		if tile == engine.Flag {
+			fill(w.img, image.Rect(r.Min.X+r.Dx()/4, r.Min.Y+r.Dy()*3/16, r.Min.X+r.Dx()*5/8, r.Min.Y+r.Dy()*9/16),
+				color.RGBA{220, 30, 30, 255})
+			fill(w.img, image.Rect(r.Min.X+r.Dx()*9/16, r.Min.Y+r.Dy()*3/16, r.Min.X+r.Dx()*11/16, r.Max.Y-r.Dy()/4), digitColour)
+			fill(w.img, image.Rect(r.Min.X+r.Dx()*3/8, r.Max.Y-r.Dy()/4, r.Min.X+r.Dx()*7/8, r.Max.Y-r.Dy()*3/16), digitColour)
+		}
+	default:
+		fill(w.img, r, openLineColour)
+		fill(w.img, image.Rectangle{r.Min.Add(image.Pt(1, 1)), r.Max}, openColour)
+		switch {
+		case tile >= 1 && tile <= 8:
+			drawGlyph(w.img, r, int(tile), numberColours[tile])
+		case tile == engine.Bomb:
+			fill(w.img, r.Inset(r.Dx()/4), digitColour)
+			fill(w.img, r.Inset(r.Dx()*3/8), color.RGBA{220, 30, 30, 255})
+		}
+	}
+}
+
+// setField draws tiles of a board in text form
+func (w *testWindow) setField(rows []string) engine.Board {
+	board, err := engine.ParseBoard(rows)
+	if err != nil {
+		panic(err)
+	}
+	for y := range board {
+		for x, tile := range board[y] {
+			w.setTile(x, y, tile)
+		}
+	}
+	return board
+}
+
+// setCounter draws the mine counter, right-aligned like the game does
+func (w *testWindow) setCounter(value int) {
+	for n := uint(0); n < maxCounterDigits; n++ {
+		r := w.vision.digitSquare(w.img, n).Bounds()
+		fill(w.img, r, backgroundColour)
+		if n == 0 || value > 0 {
+			drawGlyph(w.img, r, value%10, digitColour)
+		}
+		value /= 10
+	}
+}
+
+// setTimer draws the timer, left-aligned
+func (w *testWindow) setTimer(seconds int) {
+	digits := []int{}
+	for ; seconds > 0 || len(digits) == 0; seconds /= 10 {
+		digits = append([]int{seconds % 10}, digits...)
+	}
+	for n := uint(0); n < maxCounterDigits; n++ {
+		r := w.vision.timerSquare(w.img, n).Bounds()
+		fill(w.img, r, backgroundColour)
+		if int(n) < len(digits) {
+			drawGlyph(w.img, r, digits[n], digitColour)
+		}
+	}
+}
+
+// restoreTables undoes calibrations applied by a test
+func restoreTables() func() {
+	tiles, digits := tileHashes, digitHashes
+	return func() {
+		setTileHashes(tiles)
+		setDigitHashes(digits)
+	}
+}
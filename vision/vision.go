@@ -0,0 +1,228 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/jBugman/imghash"
+
+	"../engine"
+)
+
+// Window geometry of the macOS Minesweeper
+const (
+	TileSize        = 32 // 64px on retina, other sizes are found by DetectGrid
+	HeaderHeight    = 22
+	FooterHeight    = 31
+	restartMessageW = 214
+	restartMessageH = 32
+)
+const (
+	messageMask   ImageHash = 0x0300007E7E000000 // bits set in the hash of the end of game message
+	zeroBombsHash ImageHash = 0xFF8F2737373787CF
+)
+
+// ImageHash represents average image hash
+type ImageHash uint64
+
+// referenceTileHashes are hashes of the macOS Minesweeper tiles
+var referenceTileHashes = map[ImageHash]engine.Tile{
+	0x0000000000000000: engine.Unknown, // Unknown tile (or maybe OpenSpace before colour check)
+	0xFFC3C3E7E7E3E3FF: 1,
+	0xFFC3E3E7CFC3E3FF: 2,
+	0xFFE3C3C7E7C3E3FF: 3,
+	0xFFEFC3C3E3E7EFFF: 4,
+	0xFFE3C3CFE3E3E3FF: 5,
+	0xFFE7C3C3E3E3E7FF: 6,
+	0xFFF3F7E7E7C3C3FF: 7,
+	0xFFE7C3C3E3C3E7FF: 8,
+	0xFFF7F7C3C381F3FF: engine.Flag,
+	0xFFFFC3C3C3C3FFFF: engine.Bomb,
+}
+
+// tileHashes are used for recognition, a calibration may replace them
+var tileHashes = referenceTileHashes
+
+// Vision recognizes game state on window screenshots
+type Vision struct {
+	grid          Grid
+	width, height uint
+	timerHash     ImageHash
+	bombCountHash ImageHash
+	minesLeft     int
+	counterRead   bool
+	counterWarned bool
+	seconds       int
+	timerRead     bool
+	confidence    [][]float64
+	// seen on the last read field
+	message  bool
+	bombs    bool
+	unknowns int
+	readOK   bool
+	// MaxDistance is the largest Hamming distance between a tile hash and a known one
+	MaxDistance int
+	// Recognizer tells tiles apart, hashes within MaxDistance when nil
+	Recognizer Recognizer
+}
+
+// New creates Vision for a field of a given size in tiles laid out like the macOS Minesweeper
+func New(width, height uint) *Vision {
+	return NewWithGrid(Grid{Origin: image.Pt(0, HeaderHeight), Pitch: TileSize, Columns: int(width), Rows: int(height)})
+}
+
+// NewWithGrid creates Vision for a field on a given tile lattice, see DetectGrid
+func NewWithGrid(grid Grid) *Vision {
+	confidence := make([][]float64, grid.Rows)
+	for y := range confidence {
+		confidence[y] = make([]float64, grid.Columns)
+	}
+	return &Vision{
+		grid:        grid,
+		width:       uint(grid.Columns),
+		height:      uint(grid.Rows),
+		confidence:  confidence,
+		MaxDistance: DefaultMaxDistance,
+	}
+}
+
+// Grid returns the tile lattice
+func (v Vision) Grid() Grid {
+	return v.grid
+}
+
+// Confidence returns how sure recognition was about a tile when it was last read
+func (v Vision) Confidence(x, y int) float64 {
+	return v.confidence[y][x]
+}
+
+// Bounds returns game area of a window screenshot: the field and the footer below it
+func (v Vision) Bounds() image.Rectangle {
+	bounds := v.grid.Field()
+	bounds.Max.Y += v.grid.scale(FooterHeight)
+	return bounds
+}
+
+// Cleared reports that the bomb counter shows zero
+func (v Vision) Cleared() bool {
+	return v.bombCountHash == zeroBombsHash || v.counterRead && v.minesLeft == 0
+}
+
+// GameState tells the state of the game on the last read field. Revealed bombs mean
+// a lost game, the end of game message without them means a victory, as does a zero
+// counter with no unknown tiles left. There is no face indicator to read like in the
+// Windows game: the header of the macOS Minesweeper is only the title bar and its footer
+// holds the counter and the timer.
+func (v Vision) GameState() engine.GameState {
+	switch {
+	case v.bombs:
+		return engine.Lost
+	case v.message:
+		return engine.Won
+	case v.readOK && v.unknowns == 0 && v.counterRead && v.minesLeft == 0:
+		return engine.Won
+	}
+	return engine.InProgress
+}
+
+// readMessage looks for the end of game message in the middle of the field.
+// Its hash has to contain all bits of messageMask, as the board behind it changes.
+func (v *Vision) readMessage(img *image.RGBA) {
+	field := v.grid.Field()
+	center := field.Min.Add(field.Max).Div(2)
+	half := image.Pt(v.grid.scale(restartMessageW)/2, v.grid.scale(restartMessageH)/2)
+	area := image.Rectangle{center.Sub(half), center.Add(half)}.Intersect(img.Bounds())
+	if area.Empty() {
+		v.message = false
+		return
+	}
+	hash := ImageHash(imghash.Average(img.SubImage(area)))
+	v.message = hash&messageMask == messageMask
+}
+
+// ReadField recognizes tiles on a cropped screenshot
+func (v *Vision) ReadField(img *image.RGBA, field engine.Board, unknownsOnly bool) error {
+	return v.recognize(img, field, unknownsOnly)
+}
+
+// recognize reads the counter and the tiles, the image may be cropped or a whole window
+func (v *Vision) recognize(img *image.RGBA, field engine.Board, unknownsOnly bool) error {
+	v.readCounter(img)
+	v.readTimer(img)
+	v.readMessage(img)
+
+	// all tiles are read even after a failure, so that revealed bombs still tell a lost game
+	var x, y uint
+	var firstErr error
+	recognizer := v.Recognizer
+	if recognizer == nil {
+		recognizer = HashRecognizer{MaxDistance: v.MaxDistance}
+	}
+	for y = 0; y < v.height; y++ {
+		for x = 0; x < v.width; x++ {
+			skip := unknownsOnly && field[y][x] != engine.Unknown
+			if skip {
+				continue
+			}
+			match, err := recognizer.RecognizeTile(v.tileImage(img, x, y))
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("Tile %d %d: %v", x, y, err)
+				}
+				continue
+			}
+			field[y][x] = match.Tile
+			v.confidence[y][x] = match.Confidence
+		}
+	}
+	v.readOK = firstErr == nil
+	v.bombs = field.Contains(engine.Bomb)
+	v.unknowns = 0
+	for _, row := range field {
+		for _, tile := range row {
+			if tile == engine.Unknown {
+				v.unknowns++
+			}
+		}
+	}
+	return firstErr
+}
+
+// tileImage returns a tile of a screenshot
+func (v Vision) tileImage(img *image.RGBA, x, y uint) image.Image {
+	return img.SubImage(v.grid.Tile(int(x), int(y)))
+}
+
+// RecognizeTile returns value of a single tile image
+func RecognizeTile(tile image.Image) (engine.Tile, error) {
+	match, err := recognizeTile(tile, DefaultMaxDistance)
+	return match.Tile, err
+}
+
+// recognizeTile matches a tile image with the nearest known hash
+func recognizeTile(tile image.Image, maxDistance int) (TileMatch, error) {
+	hash := ImageHash(imghash.Average(tile))
+	match, close, err := nearest(hash, tileKeys(), maxDistance)
+	if close != nil {
+		if resolved, ok := resolve(tile, hash, match, close, tileHoles); ok {
+			match, err = resolved, nil
+		}
+	}
+	if err != nil {
+		return TileMatch{engine.Unknown, match}, err
+	}
+	value := tileHashes[match.Hash]
+	if value == engine.Unknown {
+		// tile is a subimage, so we need its offset
+		coords := tile.Bounds().Min
+		col := tile.(*image.RGBA).RGBAAt(coords.X+1, coords.Y+1)
+
+		avg := (uint(col.R) + uint(col.G) + uint(col.B)) / 3
+		if avg < 180 { // if it is more purple than white
+			value = engine.Unknown
+		} else {
+			value = engine.OpenSpace
+		}
+	}
+	return TileMatch{value, match}, nil
+}
@@ -0,0 +1,122 @@
+package keysym
+
+// Sym is an X keysym, translated to a key code of the current keyboard mapping when pressed
+type Sym uint
+
+// Keysyms, see X11/keysymdef.h
+const (
+	KeyControl Sym = 0xFFE3 // Control_L
+	KeyShift   Sym = 0xFFE1 // Shift_L
+	KeyAlt     Sym = 0xFFE9 // Alt_L
+	KeySuper   Sym = 0xFFEB // Super_L
+
+	KeyRightControl Sym = 0xFFE4 // Control_R
+	KeyRightShift   Sym = 0xFFE2 // Shift_R
+	KeyRightAlt     Sym = 0xFFEA // Alt_R
+	KeyCapsLock     Sym = 0xFFE5
+
+	KeyReturn    Sym = 0xFF0D
+	KeyEscape    Sym = 0xFF1B
+	KeySpace     Sym = 0x0020
+	KeyTab       Sym = 0xFF09
+	KeyBackSpace Sym = 0xFF08
+	KeyDelete    Sym = 0xFFFF
+	KeyHome      Sym = 0xFF50
+	KeyLeft      Sym = 0xFF51
+	KeyUp        Sym = 0xFF52
+	KeyRight     Sym = 0xFF53
+	KeyDown      Sym = 0xFF54
+	KeyPageUp    Sym = 0xFF55
+	KeyPageDown  Sym = 0xFF56
+	KeyEnd       Sym = 0xFF57
+
+	KeyMinus        Sym = 0x002D
+	KeyEqual        Sym = 0x003D
+	KeyLeftBracket  Sym = 0x005B
+	KeyRightBracket Sym = 0x005D
+	KeyBackslash    Sym = 0x005C
+	KeySemicolon    Sym = 0x003B
+	KeyQuote        Sym = 0x0027
+	KeyComma        Sym = 0x002C
+	KeyPeriod       Sym = 0x002E
+	KeySlash        Sym = 0x002F
+	KeyGrave        Sym = 0x0060
+
+	KeyF1  Sym = 0xFFBE
+	KeyF2  Sym = 0xFFBF
+	KeyF3  Sym = 0xFFC0
+	KeyF4  Sym = 0xFFC1
+	KeyF5  Sym = 0xFFC2
+	KeyF6  Sym = 0xFFC3
+	KeyF7  Sym = 0xFFC4
+	KeyF8  Sym = 0xFFC5
+	KeyF9  Sym = 0xFFC6
+	KeyF10 Sym = 0xFFC7
+	KeyF11 Sym = 0xFFC8
+	KeyF12 Sym = 0xFFC9
+	KeyF13 Sym = 0xFFCA
+	KeyF14 Sym = 0xFFCB
+	KeyF15 Sym = 0xFFCC
+	KeyF16 Sym = 0xFFCD
+	KeyF17 Sym = 0xFFCE
+	KeyF18 Sym = 0xFFCF
+	KeyF19 Sym = 0xFFD0
+	KeyF20 Sym = 0xFFD1
+
+	KeyKP0        Sym = 0xFFB0
+	KeyKP1        Sym = 0xFFB1
+	KeyKP2        Sym = 0xFFB2
+	KeyKP3        Sym = 0xFFB3
+	KeyKP4        Sym = 0xFFB4
+	KeyKP5        Sym = 0xFFB5
+	KeyKP6        Sym = 0xFFB6
+	KeyKP7        Sym = 0xFFB7
+	KeyKP8        Sym = 0xFFB8
+	KeyKP9        Sym = 0xFFB9
+	KeyKPDecimal  Sym = 0xFFAE
+	KeyKPAdd      Sym = 0xFFAB
+	KeyKPSubtract Sym = 0xFFAD
+	KeyKPMultiply Sym = 0xFFAA
+	KeyKPDivide   Sym = 0xFFAF
+	KeyKPEqual    Sym = 0xFFBD
+	KeyKPEnter    Sym = 0xFF8D
+	KeyClear      Sym = 0xFF0B
+
+	Key0 Sym = 0x0030
+	Key1 Sym = 0x0031
+	Key2 Sym = 0x0032
+	Key3 Sym = 0x0033
+	Key4 Sym = 0x0034
+	Key5 Sym = 0x0035
+	Key6 Sym = 0x0036
+	Key7 Sym = 0x0037
+	Key8 Sym = 0x0038
+	Key9 Sym = 0x0039
+
+	KeyA Sym = 0x0061
+	KeyB Sym = 0x0062
+	KeyC Sym = 0x0063
+	KeyD Sym = 0x0064
+	KeyE Sym = 0x0065
+	KeyF Sym = 0x0066
+	KeyG Sym = 0x0067
+	KeyH Sym = 0x0068
+	KeyI Sym = 0x0069
+	KeyJ Sym = 0x006A
+	KeyK Sym = 0x006B
+	KeyL Sym = 0x006C
+	KeyM Sym = 0x006D
+	KeyN Sym = 0x006E
+	KeyO Sym = 0x006F
+	KeyP Sym = 0x0070
+	KeyQ Sym = 0x0071
+	KeyR Sym = 0x0072
+	KeyS Sym = 0x0073
+	KeyT Sym = 0x0074
+	KeyU Sym = 0x0075
+	KeyV Sym = 0x0076
+	KeyW Sym = 0x0077
+	KeyX Sym = 0x0078
+	KeyY Sym = 0x0079
+	KeyZ Sym = 0x007A
+)
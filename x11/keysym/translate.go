@@ -0,0 +1,96 @@
+package keysym
+
+import (
+	"fmt"
+
+	"../../keys"
+)
+
+// fromKey maps platform-neutral keys to keysyms. Fn has none, keyboards handle it themselves.
+var fromKey = map[keys.Key]Sym{
+	keys.Command: KeySuper,
+	keys.Control: KeyControl,
+	keys.Shift:   KeyShift,
+	keys.Option:  KeyAlt,
+
+	keys.RightControl: KeyRightControl,
+	keys.RightShift:   KeyRightShift,
+	keys.RightOption:  KeyRightAlt,
+
+	keys.F1: KeyF1, keys.F2: KeyF2, keys.F3: KeyF3, keys.F4: KeyF4, keys.F5: KeyF5, keys.F6: KeyF6,
+	keys.F7: KeyF7, keys.F8: KeyF8, keys.F9: KeyF9, keys.F10: KeyF10, keys.F11: KeyF11, keys.F12: KeyF12,
+	keys.F13: KeyF13, keys.F14: KeyF14, keys.F15: KeyF15, keys.F16: KeyF16,
+	keys.F17: KeyF17, keys.F18: KeyF18, keys.F19: KeyF19, keys.F20: KeyF20,
+
+	keys.KeypadDecimal:  KeyKPDecimal,
+	keys.KeypadPlus:     KeyKPAdd,
+	keys.KeypadMinus:    KeyKPSubtract,
+	keys.KeypadMultiply: KeyKPMultiply,
+	keys.KeypadDivide:   KeyKPDivide,
+	keys.KeypadEquals:   KeyKPEqual,
+	keys.KeypadEnter:    KeyKPEnter,
+	keys.KeypadClear:    KeyClear,
+
+	keys.Escape:    KeyEscape,
+	keys.Return:    KeyReturn,
+	keys.Tab:       KeyTab,
+	keys.Space:     KeySpace,
+	keys.Backspace: KeyBackSpace,
+	keys.Delete:    KeyDelete,
+	keys.Home:      KeyHome,
+	keys.End:       KeyEnd,
+	keys.PageUp:    KeyPageUp,
+	keys.PageDown:  KeyPageDown,
+	keys.Left:      KeyLeft,
+	keys.Right:     KeyRight,
+	keys.Up:        KeyUp,
+	keys.Down:      KeyDown,
+	keys.CapsLock:  KeyCapsLock,
+
+	keys.Minus:        KeyMinus,
+	keys.Equal:        KeyEqual,
+	keys.LeftBracket:  KeyLeftBracket,
+	keys.RightBracket: KeyRightBracket,
+	keys.Backslash:    KeyBackslash,
+	keys.Semicolon:    KeySemicolon,
+	keys.Quote:        KeyQuote,
+	keys.Comma:        KeyComma,
+	keys.Period:       KeyPeriod,
+	keys.Slash:        KeySlash,
+	keys.Grave:        KeyGrave,
+}
+
+func init() {
+	// letters and digits are consecutive in both
+	for k := keys.A; k <= keys.Z; k++ {
+		fromKey[k] = KeyA + Sym(k-keys.A)
+	}
+	for k := keys.Num0; k <= keys.Num9; k++ {
+		fromKey[k] = Key0 + Sym(k-keys.Num0)
+	}
+	for k := keys.Keypad0; k <= keys.Keypad9; k++ {
+		fromKey[k] = KeyKP0 + Sym(k-keys.Keypad0)
+	}
+}
+
+// FromKey translates a platform-neutral key
+func FromKey(k keys.Key) (Sym, bool) {
+	sym, ok := fromKey[k]
+	return sym, ok
+}
+
+// FromChord translates a chord into keysyms of its key and modifiers
+func FromChord(c keys.Chord) (key Sym, modifiers []Sym, err error) {
+	var ok bool
+	if key, ok = FromKey(c.Key); !ok {
+		return 0, nil, fmt.Errorf("No keysym for %s", c.Key)
+	}
+	for _, m := range c.Modifiers {
+		sym, ok := FromKey(m)
+		if !ok {
+			return 0, nil, fmt.Errorf("No keysym for %s", m)
+		}
+		modifiers = append(modifiers, sym)
+	}
+	return key, modifiers, nil
+}
@@ -0,0 +1,28 @@
+package keysym
+
+import (
+	"testing"
+
+	"../../keys"
+)
+
+func TestEveryKeyHasSym(t *testing.T) {
+	seen := map[Sym]keys.Key{}
+	for k := keys.Command; k <= keys.Grave; k++ {
+		sym, ok := FromKey(k)
+		if k == keys.Function {
+			if ok {
+				t.Errorf("fn translated to %#x", sym)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("no keysym for %s", k)
+			continue
+		}
+		if other, ok := seen[sym]; ok {
+			t.Errorf("%s and %s share keysym %#x", k, other, sym)
+		}
+		seen[sym] = k
+	}
+}
@@ -0,0 +1,86 @@
+//go:build linux && x11test
+// +build linux,x11test
+
+// Windows for the tests to find and capture. Cgo cannot be used in _test.go files,
+// so they live here and are only built with the x11test tag.
+
+package x11
+
+/*
+#include <stdlib.h>
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
+
+// Plain top-level window with a background colour, mapped or not
+Window createWindow(Display* d, const char* title, int width, int height, unsigned long colour, int mapped) {
+	Window w = XCreateSimpleWindow(d, DefaultRootWindow(d), 0, 0, width, height, 0, 0, colour);
+	XStoreName(d, w, title);
+	if (mapped) {
+		XMapRaised(d, w);
+	}
+	XSync(d, False);
+	return w;
+}
+
+// Mapped window on a DirectColor visual, whose pixels index a colormap. 0 when the screen has no such visual.
+Window createDirectColorWindow(Display* d, int width, int height) {
+	XVisualInfo info;
+	if (!XMatchVisualInfo(d, DefaultScreen(d), 24, DirectColor, &info)) {
+		return 0;
+	}
+	XSetWindowAttributes attributes;
+	attributes.colormap = XCreateColormap(d, DefaultRootWindow(d), info.visual, AllocNone);
+	attributes.border_pixel = 0;
+	attributes.background_pixel = 0;
+	Window w = XCreateWindow(d, DefaultRootWindow(d), 0, 0, width, height, 0, info.depth, InputOutput, info.visual,
+		CWColormap | CWBorderPixel | CWBackPixel, &attributes);
+	XMapRaised(d, w);
+	XSync(d, False);
+	return w;
+}
+*/
+import "C"
+import "unsafe"
+
+// createWindow opens a window of a solid colour, tests use it on a bare X server without other clients
+func createWindow(title string, width, height int, colour uint32, mapped bool) (int, error) {
+	d, err := connect()
+	if err != nil {
+		return 0, err
+	}
+	cTitle := C.CString(title)
+	defer C.free(unsafe.Pointer(cTitle))
+	var w C.Window
+	err = trap(d, func() {
+		var m C.int
+		if mapped {
+			m = 1
+		}
+		w = C.createWindow(d, cTitle, C.int(width), C.int(height), C.ulong(colour), m)
+	})
+	return int(w), err
+}
+
+// createDirectColorWindow opens a window on a DirectColor visual, 0 when the screen has none
+func createDirectColorWindow(width, height int) (int, error) {
+	d, err := connect()
+	if err != nil {
+		return 0, err
+	}
+	var w C.Window
+	err = trap(d, func() {
+		w = C.createDirectColorWindow(d, C.int(width), C.int(height))
+	})
+	return int(w), err
+}
+
+// destroyWindow closes a window made by createWindow or createDirectColorWindow
+func destroyWindow(windowID int) error {
+	d, err := connect()
+	if err != nil {
+		return err
+	}
+	return trap(d, func() {
+		C.XDestroyWindow(d, C.Window(windowID))
+	})
+}
@@ -0,0 +1,426 @@
+//go:build linux
+// +build linux
+
+package x11
+
+/*
+#cgo LDFLAGS: -lX11 -lXtst
+#include <stdlib.h>
+#include <string.h>
+#include <X11/Xlib.h>
+#include <X11/Xatom.h>
+#include <X11/Xutil.h>
+#include <X11/extensions/XTest.h>
+
+// Title of a window: EWMH UTF-8 name when set, legacy WM_NAME otherwise. Free with free().
+char* windowTitle(Display* d, Window w) {
+	Atom utf8 = XInternAtom(d, "UTF8_STRING", False);
+	Atom netName = XInternAtom(d, "_NET_WM_NAME", False);
+	Atom type;
+	int format;
+	unsigned long count, after;
+	unsigned char* data = NULL;
+	char* title = NULL;
+	if (XGetWindowProperty(d, w, netName, 0, 1024, False, utf8, &type, &format, &count, &after, &data) == Success && data) {
+		if (count > 0) {
+			title = strndup((char*)data, count);
+		}
+		XFree(data);
+	}
+	if (!title) {
+		char* name = NULL;
+		if (XFetchName(d, w, &name) && name) {
+			title = strdup(name);
+			XFree(name);
+		}
+	}
+	return title;
+}
+
+// Process ID of a window owner from _NET_WM_PID, or 0
+long windowPID(Display* d, Window w) {
+	Atom pidAtom = XInternAtom(d, "_NET_WM_PID", False);
+	Atom type;
+	int format;
+	unsigned long count, after;
+	unsigned char* data = NULL;
+	long pid = 0;
+	if (XGetWindowProperty(d, w, pidAtom, 0, 1, False, XA_CARDINAL, &type, &format, &count, &after, &data) == Success && data) {
+		if (count > 0) {
+			pid = *(long*)data;
+		}
+		XFree(data);
+	}
+	return pid;
+}
+
+// Children of a window, free with XFree()
+Window* windowChildren(Display* d, Window w, unsigned int* count) {
+	Window root, parent, *children = NULL;
+	if (!XQueryTree(d, w, &root, &parent, &children, count)) {
+		*count = 0;
+		return NULL;
+	}
+	return children;
+}
+
+// Pixels hold colour values themselves only on TrueColor visuals, others index a colormap
+int isTrueColor(Visual* v) {
+	return v->class == TrueColor;
+}
+
+// Copies a TrueColor image into RGBA bytes, returns 0 without copying when a colour mask is empty
+int copyPixels(XImage* img, unsigned char* out) {
+	int shifts[3];
+	unsigned long masks[3] = {img->red_mask, img->green_mask, img->blue_mask};
+	for (int c = 0; c < 3; c++) {
+		if (!masks[c]) {
+			return 0;
+		}
+		shifts[c] = 0;
+		while (!((masks[c] >> shifts[c]) & 1)) {
+			shifts[c]++;
+		}
+	}
+	for (int y = 0; y < img->height; y++) {
+		for (int x = 0; x < img->width; x++) {
+			unsigned long p = XGetPixel(img, x, y);
+			unsigned char* o = out + 4 * (y * img->width + x);
+			for (int c = 0; c < 3; c++) {
+				o[c] = ((p & masks[c]) >> shifts[c]) * 255 / (masks[c] >> shifts[c]);
+			}
+			o[3] = 255;
+		}
+	}
+	return 1;
+}
+
+void destroyImage(XImage* img) {
+	XDestroyImage(img);
+}
+
+// First X error since resetError. The handler records it instead of letting Xlib exit the process,
+// calls between resetError and takeError are serialised by trap.
+static int lastError = 0;
+
+static int recordError(Display* d, XErrorEvent* e) {
+	if (!lastError) {
+		lastError = e->error_code;
+	}
+	return 0;
+}
+
+void installErrorHandler() {
+	XSetErrorHandler(recordError);
+}
+
+void resetError(Display* d) {
+	XSync(d, False);
+	lastError = 0;
+}
+
+// Waits for replies of the calls so far and returns their first error code, or 0
+int takeError(Display* d) {
+	XSync(d, False);
+	int code = lastError;
+	lastError = 0;
+	return code;
+}
+
+void errorText(Display* d, int code, char* buffer, int length) {
+	XGetErrorText(d, code, buffer, length);
+}
+
+// Asks the window manager to activate a window, and raises and focuses it directly for bare servers like Xvfb
+void activate(Display* d, Window w) {
+	XEvent e;
+	memset(&e, 0, sizeof(e));
+	e.xclient.type = ClientMessage;
+	e.xclient.window = w;
+	e.xclient.message_type = XInternAtom(d, "_NET_ACTIVE_WINDOW", False);
+	e.xclient.format = 32;
+	e.xclient.data.l[0] = 1; // request from an application
+	e.xclient.data.l[1] = CurrentTime;
+	XSendEvent(d, DefaultRootWindow(d), False, SubstructureRedirectMask | SubstructureNotifyMask, &e);
+	XMapRaised(d, w);
+	XSetInputFocus(d, w, RevertToParent, CurrentTime);
+	XFlush(d);
+}
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"./keysym"
+)
+
+// WindowMeta contains some info about an X window
+type WindowMeta struct {
+	ID       int
+	OwnerPID int
+	Title    string
+	Bounds   image.Rectangle // in root window coordinates
+}
+
+var (
+	display     *C.Display
+	displayErr  error
+	displayOnce sync.Once
+)
+
+// connect opens the display named by $DISPLAY once
+func connect() (*C.Display, error) {
+	displayOnce.Do(func() {
+		display = C.XOpenDisplay(nil)
+		if display == nil {
+			displayErr = errors.New("Cannot open display " + os.Getenv("DISPLAY"))
+			return
+		}
+		var event, errorBase, major, minor C.int
+		if C.XTestQueryExtension(display, &event, &errorBase, &major, &minor) == 0 {
+			displayErr = errors.New("X server does not support XTest")
+		}
+		C.installErrorHandler()
+	})
+	return display, displayErr
+}
+
+// trapMu serialises trapped calls, as the error handler is process-wide
+var trapMu sync.Mutex
+
+// trap runs Xlib calls and returns the first X error they caused. Without it, Xlib prints
+// the error and exits, as on a window closed while it is looked at or captured.
+func trap(d *C.Display, calls func()) error {
+	trapMu.Lock()
+	defer trapMu.Unlock()
+	C.resetError(d)
+	calls()
+	code := C.takeError(d)
+	if code == 0 {
+		return nil
+	}
+	var text [256]C.char
+	C.errorText(d, code, &text[0], C.int(len(text)))
+	return fmt.Errorf("X error %d: %s", int(code), C.GoString(&text[0]))
+}
+
+// FindWindow returns the first viewable window with a given title.
+// Windows closed during the search are skipped.
+func FindWindow(title string) (WindowMeta, error) {
+	d, err := connect()
+	if err != nil {
+		return WindowMeta{}, err
+	}
+	var info WindowMeta
+	var visit func(w C.Window) bool
+	visit = func(w C.Window) bool {
+		var found bool
+		var children *C.Window
+		var count C.uint
+		err := trap(d, func() {
+			var attributes C.XWindowAttributes
+			if C.XGetWindowAttributes(d, w, &attributes) != 0 && attributes.map_state == C.IsViewable {
+				if cTitle := C.windowTitle(d, w); cTitle != nil {
+					name := C.GoString(cTitle)
+					C.free(unsafe.Pointer(cTitle))
+					if name == title {
+						var x, y C.int
+						var child C.Window
+						C.XTranslateCoordinates(d, w, C.XDefaultRootWindow(d), 0, 0, &x, &y, &child)
+						info.ID = int(w)
+						info.Title = name
+						info.OwnerPID = int(C.windowPID(d, w))
+						info.Bounds = image.Rect(int(x), int(y), int(x+attributes.width), int(y+attributes.height))
+						found = true
+						return
+					}
+				}
+			}
+			children = C.windowChildren(d, w, &count)
+		})
+		if children != nil {
+			defer C.XFree(unsafe.Pointer(children))
+		}
+		if err != nil {
+			// the window is gone, BadWindow
+			return false
+		}
+		if found || children == nil {
+			return found
+		}
+		for _, child := range (*[1 << 20]C.Window)(unsafe.Pointer(children))[:count:count] {
+			if visit(child) {
+				return true
+			}
+		}
+		return false
+	}
+	if visit(C.XDefaultRootWindow(d)) {
+		return info, nil
+	}
+	return info, errors.New("Window not found")
+}
+
+// TakeScreenshot captures contents of a window with provided ID
+func TakeScreenshot(windowID int) (*image.RGBA, error) {
+	d, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	var attributes C.XWindowAttributes
+	var ximg *C.XImage
+	trueColor := true
+	err = trap(d, func() {
+		if C.XGetWindowAttributes(d, C.Window(windowID), &attributes) == 0 {
+			return
+		}
+		if trueColor = C.isTrueColor(attributes.visual) != 0; trueColor {
+			ximg = C.XGetImage(d, C.Window(windowID), 0, 0, C.uint(attributes.width), C.uint(attributes.height), C.XAllPlanes(), C.ZPixmap)
+		}
+	})
+	if ximg != nil {
+		defer C.destroyImage(ximg)
+	}
+	if err != nil {
+		// BadWindow for a closed window, BadMatch for one not viewable
+		return nil, fmt.Errorf("Cannot capture the window: %v", err)
+	}
+	if !trueColor {
+		return nil, errors.New("Cannot capture the window: only TrueColor visuals are supported")
+	}
+	if ximg == nil {
+		return nil, errors.New("Cannot capture the window")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(ximg.width), int(ximg.height)))
+	if C.copyPixels(ximg, (*C.uchar)(unsafe.Pointer(&img.Pix[0]))) == 0 {
+		return nil, errors.New("Cannot capture the window: the image has no colour masks")
+	}
+	return img, nil
+}
+
+// Delays between 'down' and 'up' mouse and keyboard events
+const (
+	MouseClickDuration = 75 * time.Millisecond
+	keyPressDuration   = 20 * time.Millisecond
+)
+
+// Mouse buttons
+const (
+	leftButton   = 1
+	middleButton = 2
+	rightButton  = 3
+)
+
+// LeftClick does that it sounds
+func LeftClick(x, y int) {
+	LeftClickT(x, y, MouseClickDuration)
+}
+
+// LeftClickT is a LeftClick with a custom delay
+func LeftClickT(x, y int, delay time.Duration) {
+	genericClick(leftButton, x, y, delay)
+}
+
+// RightClick does that it sounds
+func RightClick(x, y int) {
+	RightClickT(x, y, MouseClickDuration)
+}
+
+// RightClickT is a RightClick with a custom delay
+func RightClickT(x, y int, delay time.Duration) {
+	genericClick(rightButton, x, y, delay)
+}
+
+// MiddleClickT clicks the middle button with a custom delay
+func MiddleClickT(x, y int, delay time.Duration) {
+	genericClick(middleButton, x, y, delay)
+}
+
+// BothClickT presses left and right buttons together, the classic Minesweeper chord
+func BothClickT(x, y int, delay time.Duration) {
+	d, err := connect()
+	if err != nil {
+		return
+	}
+	C.XTestFakeMotionEvent(d, -1, C.int(x), C.int(y), C.CurrentTime)
+	C.XTestFakeButtonEvent(d, leftButton, C.True, C.CurrentTime)
+	C.XTestFakeButtonEvent(d, rightButton, C.True, C.CurrentTime)
+	C.XFlush(d)
+	time.Sleep(delay)
+	C.XTestFakeButtonEvent(d, leftButton, C.False, C.CurrentTime)
+	C.XTestFakeButtonEvent(d, rightButton, C.False, C.CurrentTime)
+	C.XFlush(d)
+	time.Sleep(delay)
+}
+
+// genericClick moves the pointer to root window coordinates and clicks a button there
+func genericClick(button uint, x, y int, duration time.Duration) {
+	d, err := connect()
+	if err != nil {
+		return
+	}
+	C.XTestFakeMotionEvent(d, -1, C.int(x), C.int(y), C.CurrentTime)
+	C.XTestFakeButtonEvent(d, C.uint(button), C.True, C.CurrentTime)
+	C.XFlush(d)
+	time.Sleep(duration)
+	C.XTestFakeButtonEvent(d, C.uint(button), C.False, C.CurrentTime)
+	C.XFlush(d)
+	time.Sleep(duration)
+}
+
+func fakeKey(d *C.Display, sym keysym.Sym, down bool) {
+	code := C.XKeysymToKeycode(d, C.KeySym(sym))
+	pressed := C.Bool(C.False)
+	if down {
+		pressed = C.True
+	}
+	C.XTestFakeKeyEvent(d, C.uint(code), pressed, C.CurrentTime)
+	C.XFlush(d)
+	time.Sleep(keyPressDuration)
+}
+
+// KeyPress emulates keyboard key press
+func KeyPress(sym keysym.Sym) {
+	d, err := connect()
+	if err != nil {
+		return
+	}
+	fakeKey(d, sym, true)
+	fakeKey(d, sym, false)
+}
+
+// KeyPressWithModifier emulates keyboard press with modifier key
+func KeyPressWithModifier(sym, modifier keysym.Sym) {
+	KeyChord(sym, modifier)
+}
+
+// KeyChord emulates keyboard press holding any number of modifier keys
+func KeyChord(sym keysym.Sym, modifiers ...keysym.Sym) {
+	d, err := connect()
+	if err != nil {
+		return
+	}
+	for _, modifier := range modifiers {
+		fakeKey(d, modifier, true)
+	}
+	KeyPress(sym)
+	for i := len(modifiers) - 1; i >= 0; i-- {
+		fakeKey(d, modifiers[i], false)
+	}
+}
+
+// ActivateWindow brings a window to front and gives it keyboard focus
+func ActivateWindow(windowID int) {
+	d, err := connect()
+	if err != nil {
+		return
+	}
+	C.activate(d, C.Window(windowID))
+	time.Sleep(200 * time.Millisecond)
+}
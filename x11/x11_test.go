@@ -0,0 +1,144 @@
+//go:build linux && x11test
+// +build linux,x11test
+
+package x11
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// xvfbDisplay is the display number of the virtual server the tests start
+const xvfbDisplay = 97
+
+// TestMain runs the tests on their own Xvfb, they are skipped when it is not installed
+func TestMain(m *testing.M) {
+	server, err := startXvfb()
+	if err != nil {
+		fmt.Println("Skipping X11 tests:", err)
+		os.Unsetenv("DISPLAY")
+		os.Exit(m.Run())
+	}
+	code := m.Run()
+	server.Process.Kill()
+	server.Wait()
+	os.Exit(code)
+}
+
+func startXvfb() (*exec.Cmd, error) {
+	path, err := exec.LookPath("Xvfb")
+	if err != nil {
+		return nil, err
+	}
+	display := fmt.Sprintf(":%d", xvfbDisplay)
+	server := exec.Command(path, display, "-screen", "0", "640x480x24", "-nolisten", "tcp")
+	if err := server.Start(); err != nil {
+		return nil, err
+	}
+	socket := fmt.Sprintf("/tmp/.X11-unix/X%d", xvfbDisplay)
+	for i := 0; i < 50; i++ {
+		if _, err := os.Stat(socket); err == nil {
+			os.Setenv("DISPLAY", display)
+			return server, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	server.Process.Kill()
+	server.Wait()
+	return nil, fmt.Errorf("Xvfb did not start on %s", display)
+}
+
+func needDisplay(t *testing.T) {
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("no Xvfb")
+	}
+	if _, err := connect(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindWindowAndCapture(t *testing.T) {
+	needDisplay(t)
+	const red = 0xFF0000
+	id, err := createWindow("Mines test", 120, 80, red, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer destroyWindow(id)
+
+	info, err := FindWindow("Mines test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != id || info.Bounds.Dx() != 120 || info.Bounds.Dy() != 80 {
+		t.Errorf("found %+v, want window %d of 120x80", info, id)
+	}
+	if _, err := FindWindow("No such window"); err == nil {
+		t.Error("found a window that does not exist")
+	}
+
+	img, err := TakeScreenshot(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 120 || img.Bounds().Dy() != 80 {
+		t.Errorf("captured %v", img.Bounds())
+	}
+	if c := img.RGBAAt(60, 40); c.R != 255 || c.G != 0 || c.B != 0 {
+		t.Errorf("captured colour %v, want red", c)
+	}
+}
+
+func TestCaptureErrors(t *testing.T) {
+	needDisplay(t)
+	// XGetImage of a window that is not viewable fails with BadMatch
+	id, err := createWindow("Hidden test", 50, 50, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TakeScreenshot(id); err == nil {
+		t.Error("captured a window that is not mapped")
+	}
+	if _, err := FindWindow("Hidden test"); err == nil {
+		t.Error("found a window that is not mapped")
+	}
+
+	// a closed window fails with BadWindow
+	if err := destroyWindow(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TakeScreenshot(id); err == nil {
+		t.Error("captured a closed window")
+	}
+	if err := destroyWindow(id); err == nil {
+		t.Error("closed a window twice")
+	}
+
+	// the connection survives the errors
+	id, err = createWindow("After errors", 20, 20, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer destroyWindow(id)
+	if _, err := TakeScreenshot(id); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCaptureNeedsTrueColor(t *testing.T) {
+	needDisplay(t)
+	id, err := createDirectColorWindow(20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == 0 {
+		t.Skip("no DirectColor visual")
+	}
+	defer destroyWindow(id)
+	if _, err := TakeScreenshot(id); err == nil {
+		t.Error("captured a window whose pixels index a colormap")
+	}
+}
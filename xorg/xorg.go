@@ -0,0 +1,164 @@
+//go:build linux
+// +build linux
+
+package xorg
+
+import (
+	"image"
+	"log"
+	"time"
+
+	"../engine"
+	"../keys"
+	"../vision"
+	"../x11"
+	"../x11/keysym"
+)
+
+// DefaultTitle is the window title of GNOME Mines
+const DefaultTitle = "Mines"
+
+// DefaultNewGameChord starts a new game, Ctrl+N
+var DefaultNewGameChord = keys.Chord{Modifiers: []keys.Key{keys.Control}, Key: keys.N}
+
+// Window plays a Minesweeper clone in an X11 window
+type Window struct {
+	x, y          int
+	width, height uint
+	windowID      int
+	vision        *vision.Vision
+	// new game chord translated at start
+	newGameKey       keysym.Sym
+	newGameModifiers []keysym.Sym
+
+	Title         string
+	ClickDuration time.Duration
+	// NewGameChord is the shortcut of the game for starting over
+	NewGameChord keys.Chord
+	// MaxHashDistance is passed to recognition, see vision.Vision
+	MaxHashDistance int
+	// Recognizer tells tiles apart, average hashes when nil
+	Recognizer vision.Recognizer
+	// MiddleClickChord chords with the middle button instead of both buttons at once
+	MiddleClickChord bool
+}
+
+// New creates a frontend for a Minesweeper window on the X display named by $DISPLAY
+func New() *Window {
+	return &Window{
+		Title:           DefaultTitle,
+		NewGameChord:    DefaultNewGameChord,
+		ClickDuration:   x11.MouseClickDuration,
+		MaxHashDistance: vision.DefaultMaxDistance,
+	}
+}
+
+// Start finds the game window and brings it to front
+func (w *Window) Start() (uint, uint, error) {
+	var err error
+	w.newGameKey, w.newGameModifiers, err = keysym.FromChord(w.NewGameChord)
+	if err != nil {
+		return 0, 0, err
+	}
+	winMeta, err := x11.FindWindow(w.Title)
+	log.Printf("%+v\n", winMeta)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	w.windowID = winMeta.ID
+	w.x = winMeta.Bounds.Min.X
+	w.y = winMeta.Bounds.Min.Y
+	x11.ActivateWindow(w.windowID)
+	screenshot, err := x11.TakeScreenshot(w.windowID)
+	if err != nil {
+		return 0, 0, err
+	}
+	// X11 coordinates are pixels, no scaling between capture and input
+	grid, err := vision.WindowGrid(screenshot, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	log.Println("📐 Field grid:", grid)
+	w.width, w.height = uint(grid.Columns), uint(grid.Rows)
+	w.vision = vision.NewWithGrid(grid)
+	w.vision.MaxDistance = w.MaxHashDistance
+	w.vision.Recognizer = w.Recognizer
+	return w.width, w.height, nil
+}
+
+// SetClickDuration sets delay between mouse 'down' and 'up' events
+func (w *Window) SetClickDuration(duration time.Duration) {
+	w.ClickDuration = duration
+}
+
+// GrabScreen returns game area of the window
+func (w Window) GrabScreen() (*image.RGBA, error) {
+	img, err := x11.TakeScreenshot(w.windowID)
+	if err != nil {
+		return nil, err
+	}
+	return img.SubImage(w.vision.Bounds().Intersect(img.Bounds())).(*image.RGBA), nil
+}
+
+// ReadField recognizes the field on a fresh screenshot
+func (w *Window) ReadField(field engine.Board, unknownsOnly bool) error {
+	img, err := w.GrabScreen()
+	if err != nil {
+		return err
+	}
+	return w.vision.ReadField(img, field, unknownsOnly)
+}
+
+// Cleared reports that the bomb counter shows zero
+func (w Window) Cleared() bool {
+	return w.vision.Cleared()
+}
+
+// GameState tells whether the game on the last read field goes on
+func (w Window) GameState() engine.GameState {
+	return w.vision.GameState()
+}
+
+// MinesLeft returns the number on the bomb counter
+func (w Window) MinesLeft() (int, bool) {
+	return w.vision.MinesLeft()
+}
+
+// GameSeconds returns the number on the timer
+func (w Window) GameSeconds() (int, bool) {
+	return w.vision.GameSeconds()
+}
+
+// NewGame presses the new game chord
+func (w Window) NewGame() {
+	x11.KeyChord(w.newGameKey, w.newGameModifiers...)
+}
+
+// tileCenter returns root window coordinates of a tile
+func (w Window) tileCenter(x, y int) (int, int) {
+	center := w.vision.Grid().Center(x, y)
+	return w.x + center.X, w.y + center.Y
+}
+
+// Reveal left-clicks a tile
+func (w Window) Reveal(x, y int) {
+	cx, cy := w.tileCenter(x, y)
+	x11.LeftClickT(cx, cy, w.ClickDuration)
+}
+
+// Flag right-clicks a tile
+func (w Window) Flag(x, y int) {
+	cx, cy := w.tileCenter(x, y)
+	x11.RightClickT(cx, cy, w.ClickDuration)
+}
+
+// Chord clicks a number to reveal its unflagged neighbours
+func (w Window) Chord(x, y int) {
+	cx, cy := w.tileCenter(x, y)
+	if w.MiddleClickChord {
+		x11.MiddleClickT(cx, cy, w.ClickDuration)
+	} else {
+		x11.BothClickT(cx, cy, w.ClickDuration)
+	}
+}